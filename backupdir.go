@@ -0,0 +1,75 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// backupPrefix returns the path backups of the file with the given name start with,
+// which is in the backup directory if one is configured.
+func (l *RollingFile) backupPrefix(name string) string {
+	if l.backupDir == "" {
+		return name
+	}
+	return filepath.Join(l.backupDir, filepath.Base(name))
+}
+
+// moveBackup moves the rotated file src to dst. If they are on different filesystems,
+// src is copied and removed instead.
+func (l *RollingFile) moveBackup(src, dst string) error {
+	err := l.fs.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := l.copyBackup(src, dst); err != nil {
+		return fmt.Errorf("failed to copy backup to another filesystem: %w", err)
+	}
+	return l.fs.Remove(src)
+}
+
+// copyBackup copies src to a temporary file next to dst, syncs it and renames it to dst,
+// so that dst never holds a partial copy.
+func (l *RollingFile) copyBackup(src, dst string) error {
+	in, err := l.open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpPath := dst + tmpSuffix
+	out, err := l.fs.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, l.mode)
+	if err != nil {
+		return err
+	}
+	err = l.applyStrictMode(out, l.mode)
+	if err == nil {
+		_, err = io.Copy(out, in)
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = l.fs.Rename(tmpPath, dst)
+	}
+	if err != nil {
+		l.fs.Remove(tmpPath)
+		return err
+	}
+	l.syncDir(filepath.Dir(dst))
+	return nil
+}
+
+// syncDir commits a rename in dir to stable storage, where the platform supports it.
+func (l *RollingFile) syncDir(dir string) {
+	if d, err := l.open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,84 @@
+package rollingfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeBackups writes lines to a RollingFile with the given backup dir, rotating after every line.
+func writeBackups(t *testing.T, logPath, backupDir string, lines ...string) *RollingFile {
+	logger, err := New(logPath, WithMaxBytes(5), WithBackupDir(backupDir), WithMaxBackups(5))
+	assert.NoError(t, err)
+	for _, line := range lines {
+		_, err := logger.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	return logger
+}
+
+// TestBackupDir verifies that backups are placed in a relative backup directory and
+// found there by retention, Search and Verify.
+func TestBackupDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	logger := writeBackups(t, logPath, "archive", "one\n", "two\n", "six\n")
+
+	var found []string
+	err := logger.Search(SearchQuery{}, func(m SearchMatch) error {
+		found = append(found, strings.TrimSpace(string(m.Line)))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "six"}, found)
+	report, err := logger.Verify()
+	assert.NoError(t, err)
+	assert.Len(t, report.Unverified, 2)
+	assert.NoError(t, logger.Close())
+
+	local, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Empty(t, local)
+	archived, err := filepath.Glob(filepath.Join(tmpDir, "archive", "app.log.*"))
+	assert.NoError(t, err)
+	assert.Len(t, archived, 2)
+}
+
+// TestBackupDirAcrossFilesystems verifies that backups are copied to a backup directory on another
+// filesystem and removed from the log directory.
+func TestBackupDirAcrossFilesystems(t *testing.T) {
+	backupDir, err := os.MkdirTemp("/dev/shm", "rollingfile")
+	if err != nil {
+		t.Skipf("no tmpfs available: %v", err)
+	}
+	defer os.RemoveAll(backupDir)
+	tmpDir := t.TempDir()
+	probe := filepath.Join(tmpDir, "probe")
+	assert.NoError(t, os.WriteFile(probe, nil, 0644))
+	if err := os.Rename(probe, filepath.Join(backupDir, "probe")); !errors.Is(err, syscall.EXDEV) {
+		t.Skip("backup directory is on the same filesystem")
+	}
+
+	logPath := filepath.Join(tmpDir, "app.log")
+	logger := writeBackups(t, logPath, backupDir, "one\n", "two\n", "six\n")
+	assert.NoError(t, logger.Close())
+
+	local, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Empty(t, local)
+	archived, err := filepath.Glob(filepath.Join(backupDir, "app.log.*"))
+	assert.NoError(t, err)
+	if assert.Len(t, archived, 2) {
+		contents, err := os.ReadFile(archived[1])
+		assert.NoError(t, err)
+		assert.Equal(t, "two\n", string(contents))
+	}
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "six\n", string(contents))
+}
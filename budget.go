@@ -0,0 +1,125 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DiskBudget is a disk budget shared by multiple RollingFiles (see WithDiskBudget), e.g. all logs in a
+// directory or all files of a Manager, so that many loggers together cannot fill the log volume.
+// It is safe for concurrent use.
+type DiskBudget struct {
+	maxBytes int64
+	mu       sync.Mutex
+	files    map[*RollingFile]struct{}
+}
+
+// NewDiskBudget returns a DiskBudget of maxBytes for the active files and backups of all RollingFiles sharing it.
+func NewDiskBudget(maxBytes int64) *DiskBudget {
+	return &DiskBudget{maxBytes: maxBytes, files: map[*RollingFile]struct{}{}}
+}
+
+func (b *DiskBudget) add(l *RollingFile) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.files[l] = struct{}{}
+}
+
+func (b *DiskBudget) remove(l *RollingFile) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.files, l)
+}
+
+// budgetBackup is a backup that may be deleted to enforce a DiskBudget.
+type budgetBackup struct {
+	owner *RollingFile
+	path  string
+	size  int64
+	time  time.Time
+}
+
+// Usage returns the total size of the active files and backups of the RollingFiles sharing the budget.
+func (b *DiskBudget) Usage() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	total, _ := b.collect()
+	return total
+}
+
+// collect returns the total size of all files and the backups that may be deleted, oldest first.
+// The caller must hold the budget mutex.
+func (b *DiskBudget) collect() (int64, []budgetBackup) {
+	var total int64
+	var candidates []budgetBackup
+	for l := range b.files {
+		if info, err := l.fs.Stat(l.path); err == nil {
+			total += info.Size()
+		}
+		backups, err := l.listBackups(l.path)
+		if err != nil {
+			l.cleanupError(fmt.Errorf("failed to list backup files: %w", err))
+			continue
+		}
+		for i, file := range backups {
+			info, err := l.fs.Stat(file)
+			if err != nil {
+				continue
+			}
+			total += info.Size()
+			// Never delete the newest minBackups backups.
+			if len(backups)-i <= l.minBackups {
+				continue
+			}
+			candidates = append(candidates, budgetBackup{owner: l, path: file, size: info.Size(), time: backupTime(file, info)})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if !a.time.Equal(b.time) {
+			return a.time.Before(b.time)
+		}
+		// Backup names of the same log sort chronologically.
+		return a.path < b.path
+	})
+	return total, candidates
+}
+
+// backupTime returns the rotation time of a backup from its name, or its modification time if the name has none.
+// The modification time of a compressed backup is the time of its compression.
+func backupTime(file string, info os.FileInfo) time.Time {
+	if m := backupTimestampRegexp.FindStringSubmatch(file); len(m) == 2 {
+		if ts, err := time.ParseInLocation(backupTimeLayout, m[1], time.Local); err == nil {
+			return ts
+		}
+	}
+	return info.ModTime()
+}
+
+// enforce deletes the oldest backups across all RollingFiles sharing the budget until their total size fits.
+func (b *DiskBudget) enforce() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	total, candidates := b.collect()
+	for _, backup := range candidates {
+		if total <= b.maxBytes {
+			return
+		}
+		l := backup.owner
+		l.cleanupMutex.Lock()
+		err := l.removeBackup(backup.path)
+		l.cleanupMutex.Unlock()
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				l.cleanupError(fmt.Errorf("failed to remove backup file %q: %w", backup.path, err))
+			}
+			continue
+		}
+		total -= backup.size
+		l.backupBytes.Add(-backup.size)
+	}
+}
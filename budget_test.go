@@ -0,0 +1,77 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiskBudget verifies that the oldest backups across all RollingFiles sharing a budget are deleted first.
+func TestDiskBudget(t *testing.T) {
+	tmpDir := t.TempDir()
+	budget := NewDiskBudget(40)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	clock := func() time.Time { return now }
+
+	a, err := New(filepath.Join(tmpDir, "a.log"), WithMaxBytes(10), WithDiskBudget(budget), WithClock(clock))
+	assert.NoError(t, err)
+	b, err := New(filepath.Join(tmpDir, "b.log"), WithMaxBytes(10), WithDiskBudget(budget), WithClock(clock))
+	assert.NoError(t, err)
+
+	// a rotates first, so its backups are the oldest.
+	for _, l := range []*RollingFile{a, a, a, b, b, b} {
+		_, err = l.Write([]byte("0123456\n"))
+		assert.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+	// The budget is enforced after every rotation, which happens before the write making b exceed it.
+	// Enforcing it again converges regardless of cleanups still running, as the budget serializes them.
+	budget.enforce()
+	assert.EqualValues(t, 40, budget.Usage())
+	assert.NoError(t, a.Close())
+	assert.NoError(t, b.Close())
+
+	backupsA, err := a.listBackups(a.path)
+	assert.NoError(t, err)
+	backupsB, err := b.listBackups(b.path)
+	assert.NoError(t, err)
+	// 5 of the 6 files of 8 bytes fit into 40 bytes.
+	assert.Len(t, backupsA, 1)
+	assert.Len(t, backupsB, 2)
+	assert.Empty(t, budget.files)
+
+	var total int64
+	for _, file := range append(append(backupsA, backupsB...), a.path, b.path) {
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		total += info.Size()
+	}
+	assert.LessOrEqual(t, total, int64(40))
+}
+
+// TestDiskBudgetKeepsMinBackups verifies that the minimum number of backups of each RollingFile is kept.
+func TestDiskBudgetKeepsMinBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	budget := NewDiskBudget(1)
+	logger, err := New(filepath.Join(tmpDir, "app.log"), WithMaxBytes(10), WithMinBackups(2), WithDiskBudget(budget))
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = logger.Write([]byte("0123456\n"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	backups, err := logger.listBackups(logger.path)
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	var total int64
+	for _, file := range append(backups, logger.path) {
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		total += info.Size()
+	}
+	assert.EqualValues(t, 3*8, total)
+}
@@ -0,0 +1,40 @@
+package rollingfile
+
+import (
+	"context"
+	"fmt"
+)
+
+// cleanupContext returns the context bounding a cleanup run by the configured time budget.
+func (l *RollingFile) cleanupContext() (context.Context, context.CancelFunc) {
+	if l.cleanupBudget <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), l.cleanupBudget)
+}
+
+// bounded runs op, but stops waiting for it once ctx is done, so that a filesystem call hanging on an
+// unresponsive network filesystem cannot hold the cleanup mutex forever. The call is left running.
+func bounded(ctx context.Context, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return op()
+	}
+	done := make(chan error, 1)
+	go func() { done <- op() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cleanupInterrupted records that a cleanup run exceeded its budget. The remaining work is picked up
+// by the next cleanup, which runs after the next rotation.
+func (l *RollingFile) cleanupInterrupted(ctx context.Context) {
+	l.cleanupIncomplete.Store(true)
+	l.emit(CleanupIncomplete{Err: fmt.Errorf("cleanup exceeded its budget of %s: %w", l.cleanupBudget, ctx.Err())})
+}
@@ -0,0 +1,65 @@
+package rollingfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCleanupBudget verifies that a hanging cleanup is given up after its budget, reported through
+// events and Stats, and resumed by the cleanup after the next rotation.
+func TestCleanupBudget(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "budget.log")
+	old := time.Now().Add(-time.Hour).Format(backupTimeLayout)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, os.WriteFile(fmt.Sprintf("%s.%s.%d", logPath, old, i), []byte("old\n"), 0644))
+	}
+
+	hang := make(chan struct{})
+	incomplete := make(chan struct{}, 1)
+	var once sync.Once
+	logger, err := New(logPath,
+		WithMaxBackups(1),
+		WithMaxBytes(5),
+		WithCleanupBudget(50*time.Millisecond),
+		WithEventFunc(func(e Event) {
+			switch e.(type) {
+			case BackupDeleted:
+				// Simulate a filesystem operation hanging on the first deletion.
+				once.Do(func() { <-hang })
+			case CleanupIncomplete:
+				incomplete <- struct{}{}
+			}
+		}),
+	)
+	assert.NoError(t, err)
+
+	// The second write rotates, starting the cleanup.
+	for i := 0; i < 2; i++ {
+		_, err = logger.Write([]byte("new\n"))
+		assert.NoError(t, err)
+	}
+	select {
+	case <-incomplete:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanup was not interrupted")
+	}
+	assert.True(t, logger.Stats().CleanupIncomplete)
+	close(hang)
+
+	_, err = logger.Write([]byte("new\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("new\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	assert.False(t, logger.Stats().CleanupIncomplete)
+	backups, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
@@ -0,0 +1,53 @@
+// Command rollingfile-verify checks the integrity of the backups of one or more log files
+// and exits with a non-zero status if any backup is missing or corrupted.
+//
+// Usage:
+//
+//	rollingfile-verify [-v] <logfile>...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/romosch/rollingfile"
+)
+
+func main() {
+	verbose := flag.Bool("v", false, "also list verified and unverified backups")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-v] <logfile>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ok := true
+	for _, path := range flag.Args() {
+		report, err := rollingfile.Verify(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			ok = false
+			continue
+		}
+		if *verbose {
+			for _, backup := range report.Verified {
+				fmt.Printf("OK         %s\n", backup)
+			}
+			for _, backup := range report.Unverified {
+				fmt.Printf("UNVERIFIED %s\n", backup)
+			}
+		}
+		for _, problem := range report.Problems {
+			fmt.Printf("FAILED     %s: %v\n", problem.Path, problem.Err)
+		}
+		ok = ok && report.OK()
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,206 @@
+package rollingfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const (
+	defaultCompressionWorkers = 1
+	defaultCompressionQueue   = 8
+)
+
+// startCompression launches the compression worker pool.
+func (l *RollingFile) startCompression() {
+	if l.compressionWorkers <= 0 {
+		l.compressionWorkers = defaultCompressionWorkers
+	}
+	if l.compressionQueueSize <= 0 {
+		l.compressionQueueSize = defaultCompressionQueue
+	}
+	l.compressionQueue = make(chan string, l.compressionQueueSize)
+	for i := 0; i < l.compressionWorkers; i++ {
+		l.compressionWaitGroup.Add(1)
+		go l.compressionWorker()
+	}
+}
+
+// stopCompression closes the queue and waits for queued and skipped backups to be compressed.
+func (l *RollingFile) stopCompression() {
+	if l.compressionQueue == nil {
+		return
+	}
+	l.retrySkippedCompression(true)
+	close(l.compressionQueue)
+	l.compressionWaitGroup.Wait()
+	l.compressionQueue = nil
+}
+
+// compressOnRotate reports whether backups are compressed right after rotation.
+// With a limit on uncompressed backups, cleanup compresses them once they exceed the limit instead.
+func (l *RollingFile) compressOnRotate() bool {
+	return l.compress && l.maxUncompressedBackups <= 0
+}
+
+// enqueueCompression schedules a backup for compression without blocking.
+// Backups already waiting for or undergoing compression are skipped.
+// If the queue is full, the error handler is notified and the backup is enqueued again
+// by the cleanup after the next rotation or by Close (see retrySkippedCompression).
+func (l *RollingFile) enqueueCompression(path string) {
+	l.queueCompression(path, false)
+}
+
+// queueCompression enqueues path unless it is already pending. Without wait, it reports and records
+// the backup as skipped if the queue is full, otherwise it blocks until the queue has room.
+func (l *RollingFile) queueCompression(path string, wait bool) {
+	if _, pending := l.compressionPending.LoadOrStore(path, struct{}{}); pending {
+		return
+	}
+	l.compressionQueued.Add(1)
+	if wait {
+		l.compressionQueue <- path
+		l.compressionSkipped.Delete(path)
+		return
+	}
+	select {
+	case l.compressionQueue <- path:
+		l.compressionSkipped.Delete(path)
+	default:
+		l.compressionQueued.Add(-1)
+		l.compressionPending.Delete(path)
+		l.compressionSkipped.Store(path, struct{}{})
+		l.errorHandler(fmt.Errorf("compression queue full, retrying %q later", path))
+	}
+}
+
+// retrySkippedCompression enqueues the backups skipped by enqueueCompression again, unless they
+// were removed in the meantime. With wait, it blocks until the queue has room for each of them.
+func (l *RollingFile) retrySkippedCompression(wait bool) {
+	l.compressionSkipped.Range(func(key, _ any) bool {
+		path := key.(string)
+		if _, err := l.fs.Stat(path); err != nil {
+			l.compressionSkipped.Delete(path)
+			return true
+		}
+		l.queueCompression(path, wait)
+		return true
+	})
+}
+
+func (l *RollingFile) compressionWorker() {
+	defer l.compressionWaitGroup.Done()
+	for path := range l.compressionQueue {
+		l.compressionQueued.Add(-1)
+		if err := l.compressBackup(path); err != nil {
+			l.errorHandler(fmt.Errorf("failed to compress backup file %q: %w", path, err))
+		}
+		l.compressionPending.Delete(path)
+	}
+}
+
+// compressBackup compresses path into path plus the compression extension and removes the original.
+// The compressed data is written to a temporary file first, which is only
+// renamed into place while holding the cleanup mutex, so cleanup never
+// counts a backup twice or sees a partially written archive.
+func (l *RollingFile) compressBackup(path string) error {
+	src, err := l.open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Already removed by cleanup.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	ext := l.compressionExt()
+	tmpPath := path + ext + tmpSuffix
+	dst, err := l.fs.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = l.applyStrictMode(dst, info.Mode())
+	if err == nil && l.compressCommand != nil {
+		err = runCompressionCommand(l.compressCommand, dst, src)
+	} else if err == nil {
+		err = gzipCopy(dst, src)
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		l.fs.Remove(tmpPath)
+		return err
+	}
+
+	l.cleanupMutex.Lock()
+	defer l.cleanupMutex.Unlock()
+	if _, err := l.fs.Stat(path); errors.Is(err, os.ErrNotExist) {
+		// Removed by cleanup while compressing.
+		return l.fs.Remove(tmpPath)
+	}
+	if err := l.fs.Rename(tmpPath, path+ext); err != nil {
+		l.fs.Remove(tmpPath)
+		return err
+	}
+	if compressed, err := l.fs.Stat(path + ext); err == nil {
+		l.backupBytes.Add(compressed.Size() - info.Size())
+	}
+	l.protectBackup(path + ext)
+	l.unprotectBackup(path)
+	if err := l.fs.Remove(path); err != nil {
+		return err
+	}
+	if l.metadata {
+		if err := l.updateCompressedMetadata(path, path+ext); err != nil {
+			l.errorHandler(fmt.Errorf("failed to update metadata for backup file %q: %w", path, err))
+		}
+	}
+	l.emit(BackupCompressed{Path: path, CompressedPath: path + ext})
+	return nil
+}
+
+// compressionCodec returns the name of the codec used to compress backups.
+func (l *RollingFile) compressionCodec() string {
+	if l.compressCommand != nil {
+		return filepath.Base(l.compressCommand[0])
+	}
+	return "gzip"
+}
+
+// compressionExt returns the file extension appended to compressed backups.
+func (l *RollingFile) compressionExt() string {
+	if l.compressCommand != nil {
+		return l.compressExt
+	}
+	return compressedSuffix
+}
+
+func gzipCopy(dst io.Writer, src io.Reader) error {
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// runCompressionCommand runs an external compressor reading src on stdin and writing dst on stdout.
+func runCompressionCommand(command []string, dst io.Writer, src io.Reader) error {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = src
+	cmd.Stdout = dst
+	return runCommand(cmd)
+}
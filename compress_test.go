@@ -0,0 +1,207 @@
+package rollingfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompressionCompressesBackups verifies that rotated backups are gzipped and the originals removed.
+func TestCompressionCompressesBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "compress.log")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithCompression(),
+		WithCompressionWorkers(2, 4),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("z", 80) + "\n"
+	for i := 0; i < 3; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, logger.Stats().CompressionQueueDepth)
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files), "expected 2 backups, found %d", len(files))
+
+	for _, f := range files {
+		assert.True(t, strings.HasSuffix(f, ".gz"), "expected %q to be compressed", f)
+
+		file, err := os.Open(f)
+		assert.NoError(t, err)
+		gz, err := gzip.NewReader(file)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, string(data))
+		file.Close()
+	}
+}
+
+// TestCompressionQueueFull ensures that backups rotated while the queue is full are reported to the
+// error handler and compressed later instead of being left uncompressed.
+func TestCompressionQueueFull(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "queue.log")
+	var mu sync.Mutex
+	var errs []error
+	busy, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithCompression(),
+		WithCompressionWorkers(1, 1),
+		WithErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}),
+		WithEventFunc(func(e Event) {
+			if _, ok := e.(BackupCompressed); ok {
+				// Hold the only worker after its first backup.
+				once.Do(func() {
+					close(busy)
+					<-release
+				})
+			}
+		}),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("z", 80) + "\n"
+	_, err = logger.Write([]byte(msg))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte(msg))
+	assert.NoError(t, err)
+	<-busy
+	// The second backup fills the queue, the third one is skipped.
+	for i := 0; i < 2; i++ {
+		_, err = logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, logger.Stats().CompressionQueueDepth)
+	close(release)
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, 0, logger.Stats().CompressionQueueDepth)
+
+	mu.Lock()
+	assert.NotEmpty(t, errs)
+	for _, err := range errs {
+		assert.Contains(t, err.Error(), "compression queue full")
+	}
+	mu.Unlock()
+	backups, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 3)
+	for _, backup := range backups {
+		assert.True(t, strings.HasSuffix(backup, ".gz"), "expected %q to be compressed", backup)
+	}
+}
+
+// TestCompressionCommand verifies that an external command is used to compress backups.
+func TestCompressionCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "cmd.log")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithCompressionCommand(".rev", "rev"),
+	)
+	assert.NoError(t, err)
+
+	msg := "abc\n" + strings.Repeat("z", 80) + "\n"
+	for i := 0; i < 2; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(logPath + ".*.rev")
+	assert.NoError(t, err)
+	if len(files) != 1 {
+		t.Fatalf("expected exactly one compressed backup, found %d", len(files))
+	}
+	data, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "cba\n"+strings.Repeat("z", 80)+"\n", string(data))
+}
+
+// TestCompressionCommandFailure ensures that a failing command is reported with its stderr
+// and the backup is left uncompressed.
+func TestCompressionCommandFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "fail.log")
+	errs := make(chan error, 1)
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithCompressionCommand(".x", "sh", "-c", "echo broken >&2; exit 3"),
+		WithErrorHandler(func(err error) { errs <- err }),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("z", 80) + "\n"
+	for i := 0; i < 2; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	err = <-errs
+	assert.Contains(t, err.Error(), "exit status 3")
+	assert.Contains(t, err.Error(), "broken")
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.False(t, strings.HasSuffix(files[0], ".x"))
+}
+
+// TestSeparateCompressionCounts verifies that the newest backups stay uncompressed while older ones
+// are compressed and limited independently.
+func TestSeparateCompressionCounts(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "counts.log")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithCompression(),
+		WithMaxUncompressedBackups(2),
+		WithMaxCompressedBackups(2),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("c", 80) + "\n"
+	for i := 0; i < 8; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	compressed, err := filepath.Glob(logPath + ".*.gz")
+	assert.NoError(t, err)
+	all, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, compressed, 2)
+	assert.Len(t, all, 4)
+}
+
+// TestCompressionCommandRejectsExtension ensures that extensions which would not
+// distinguish compressed backups from plain ones are rejected.
+func TestCompressionCommandRejectsExtension(t *testing.T) {
+	for _, ext := range []string{"", ".", "zst"} {
+		_, err := New(filepath.Join(t.TempDir(), "ext.log"), WithCompressionCommand(ext, "cat"))
+		assert.Error(t, err, "ext %q", ext)
+	}
+}
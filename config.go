@@ -0,0 +1,321 @@
+package rollingfile
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// Options is a plain-struct alternative to the functional options accepted by New.
+// Zero values leave the corresponding setting at its default, which makes the struct
+// suitable for unmarshalling from configuration files and for comparing configurations.
+type Options struct {
+	// MaxBytes is the maximum size in bytes before rotation (see WithMaxBytes).
+	MaxBytes int64 `json:"maxBytes,omitempty" yaml:"maxBytes,omitempty"`
+	// MaxSize is a human-readable alternative to MaxBytes, e.g. "250MB" (see WithMaxSize).
+	// It takes precedence over MaxBytes when set.
+	MaxSize string `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	// RotationInterval enables time-based rotation (see WithRotationInterval).
+	RotationInterval Duration `json:"rotationInterval,omitempty" yaml:"rotationInterval,omitempty"`
+	// RotationJitter randomizes time-based rotation within a window (see WithRotationJitter).
+	RotationJitter Duration `json:"rotationJitter,omitempty" yaml:"rotationJitter,omitempty"`
+	// ReadOnlyBackups makes backups read-only, and with ImmutableBackups immutable (see WithReadOnlyBackups).
+	ReadOnlyBackups  bool `json:"readOnlyBackups,omitempty" yaml:"readOnlyBackups,omitempty"`
+	ImmutableBackups bool `json:"immutableBackups,omitempty" yaml:"immutableBackups,omitempty"`
+	// IdleRelease closes the file handle after the file has not been written to for this duration (see WithIdleRelease).
+	IdleRelease Duration `json:"idleRelease,omitempty" yaml:"idleRelease,omitempty"`
+	// RotationRateLimit allows at most RotationRateLimit rotations per RotationRateWindow (see WithRotationRateLimit).
+	RotationRateLimit  int      `json:"rotationRateLimit,omitempty" yaml:"rotationRateLimit,omitempty"`
+	RotationRateWindow Duration `json:"rotationRateWindow,omitempty" yaml:"rotationRateWindow,omitempty"`
+	// RotationRetry keeps logging when a rotation fails and retries it after an exponentially growing
+	// backoff, queueing up to RetryQueueBytes of writes while no file can be opened (see WithRotationRetry).
+	RotationRetry   Duration `json:"rotationRetry,omitempty" yaml:"rotationRetry,omitempty"`
+	RetryQueueBytes int      `json:"retryQueueBytes,omitempty" yaml:"retryQueueBytes,omitempty"`
+	// TriggerFile enables rotation when the trigger file appears, checked at most once per
+	// TriggerInterval (see WithTriggerFile).
+	TriggerFile     bool     `json:"triggerFile,omitempty" yaml:"triggerFile,omitempty"`
+	TriggerPath     string   `json:"triggerPath,omitempty" yaml:"triggerPath,omitempty"`
+	TriggerInterval Duration `json:"triggerInterval,omitempty" yaml:"triggerInterval,omitempty"`
+	// BackupDir is the directory backups are placed in (see WithBackupDir).
+	BackupDir string `json:"backupDir,omitempty" yaml:"backupDir,omitempty"`
+	// MaxBackups is the maximum number of backup files to retain (see WithMaxBackups).
+	MaxBackups int `json:"maxBackups,omitempty" yaml:"maxBackups,omitempty"`
+	// MaxUncompressedBackups and MaxCompressedBackups limit the number of backups of each kind
+	// (see WithMaxUncompressedBackups and WithMaxCompressedBackups).
+	MaxUncompressedBackups int `json:"maxUncompressedBackups,omitempty" yaml:"maxUncompressedBackups,omitempty"`
+	MaxCompressedBackups   int `json:"maxCompressedBackups,omitempty" yaml:"maxCompressedBackups,omitempty"`
+	// MinBackups is the number of backup files retained regardless of age (see WithMinBackups).
+	MinBackups int `json:"minBackups,omitempty" yaml:"minBackups,omitempty"`
+	// MaxAge is the maximum age of backup files (see WithMaxAge).
+	MaxAge Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+	// MaxDays is the number of calendar days backups are retained (see WithMaxDays).
+	MaxDays int `json:"maxDays,omitempty" yaml:"maxDays,omitempty"`
+	// HealthWindow is the number of last writes and of last rotations checked by Healthy (see WithHealthWindow).
+	HealthWindow int `json:"healthWindow,omitempty" yaml:"healthWindow,omitempty"`
+	// CleanupBudget bounds the duration of every cleanup run (see WithCleanupBudget).
+	CleanupBudget Duration `json:"cleanupBudget,omitempty" yaml:"cleanupBudget,omitempty"`
+	// MaxTotalBytes limits the total size of the active file and backups (see WithMaxTotalBytes).
+	MaxTotalBytes int64 `json:"maxTotalBytes,omitempty" yaml:"maxTotalBytes,omitempty"`
+	// HardQuota makes Write fail instead of exceeding MaxTotalBytes (see WithHardQuota).
+	HardQuota bool `json:"hardQuota,omitempty" yaml:"hardQuota,omitempty"`
+	// DiskBudget is shared with other RollingFiles (see WithDiskBudget). It is not unmarshalled, as
+	// a budget is only shared if the same *DiskBudget, created by NewDiskBudget, is set on all of them.
+	DiskBudget *DiskBudget `json:"-" yaml:"-"`
+	// PersistentSequence names backups by a persisted rotation sequence (see WithPersistentSequence).
+	PersistentSequence bool `json:"persistentSequence,omitempty" yaml:"persistentSequence,omitempty"`
+	// Exclusive locks the path against other instances (see WithExclusive).
+	Exclusive bool `json:"exclusive,omitempty" yaml:"exclusive,omitempty"`
+	// LazyOpen defers opening the file to the first write (see WithLazyOpen).
+	LazyOpen bool `json:"lazyOpen,omitempty" yaml:"lazyOpen,omitempty"`
+	// Preflight verifies the log directory at creation (see WithPreflight).
+	Preflight bool `json:"preflight,omitempty" yaml:"preflight,omitempty"`
+	// Mmap enables writing through a memory mapping, configured by MmapChunkSize and
+	// MmapSyncInterval (see WithMmap).
+	Mmap             bool     `json:"mmap,omitempty" yaml:"mmap,omitempty"`
+	MmapChunkSize    int64    `json:"mmapChunkSize,omitempty" yaml:"mmapChunkSize,omitempty"`
+	MmapSyncInterval Duration `json:"mmapSyncInterval,omitempty" yaml:"mmapSyncInterval,omitempty"`
+	// DirectIO enables direct I/O with a buffer of DirectBufferSize bytes (see WithDirectIO).
+	DirectIO         bool `json:"directIO,omitempty" yaml:"directIO,omitempty"`
+	DirectBufferSize int  `json:"directBufferSize,omitempty" yaml:"directBufferSize,omitempty"`
+	// OpenFlags are the flags the log file is opened with (see WithOpenFlags).
+	OpenFlags int `json:"openFlags,omitempty" yaml:"openFlags,omitempty"`
+	// Mode is the file mode for the log file on creation (see WithMode).
+	Mode os.FileMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// StrictMode sets newly created files to exactly Mode regardless of the umask (see WithStrictMode).
+	StrictMode bool `json:"strictMode,omitempty" yaml:"strictMode,omitempty"`
+	// BackupMode is the file mode of backups (see WithBackupMode).
+	BackupMode os.FileMode `json:"backupMode,omitempty" yaml:"backupMode,omitempty"`
+
+	// LineEnding normalizes line endings of written data (see WithLineEnding).
+	LineEnding LineEnding `json:"lineEnding,omitempty" yaml:"lineEnding,omitempty"`
+
+	// RecoveryMarker marks a partial last line left by a crash (see WithRecoveryMarker).
+	RecoveryMarker bool `json:"recoveryMarker,omitempty" yaml:"recoveryMarker,omitempty"`
+
+	// TruncateOversized truncates oversized writes instead of rejecting them (see WithTruncateOversized).
+	TruncateOversized bool `json:"truncateOversized,omitempty" yaml:"truncateOversized,omitempty"`
+
+	// JSONLines enables JSON Lines validation, quarantining invalid records to
+	// QuarantinePath if set (see WithJSONLines).
+	JSONLines      bool   `json:"jsonLines,omitempty" yaml:"jsonLines,omitempty"`
+	QuarantinePath string `json:"quarantinePath,omitempty" yaml:"quarantinePath,omitempty"`
+	// Header is written at the start of every file (see WithHeader). JSONHeader is written
+	// as a JSON line instead (see WithJSONHeader); it takes precedence over Header.
+	Header     string `json:"header,omitempty" yaml:"header,omitempty"`
+	JSONHeader any    `json:"jsonHeader,omitempty" yaml:"jsonHeader,omitempty"`
+	// LengthPrefixedFrames writes binary records instead of lines (see WithLengthPrefixedFrames).
+	LengthPrefixedFrames bool `json:"lengthPrefixedFrames,omitempty" yaml:"lengthPrefixedFrames,omitempty"`
+
+	// FallbackPath is written to while the primary path is unwritable (see WithFallbackPath).
+	FallbackPath string `json:"fallbackPath,omitempty" yaml:"fallbackPath,omitempty"`
+
+	// Compress enables gzip compression of backups (see WithCompression).
+	Compress bool `json:"compress,omitempty" yaml:"compress,omitempty"`
+	// CompressionWorkers and CompressionQueueSize bound the compression worker pool
+	// (see WithCompressionWorkers).
+	CompressionWorkers   int `json:"compressionWorkers,omitempty" yaml:"compressionWorkers,omitempty"`
+	CompressionQueueSize int `json:"compressionQueueSize,omitempty" yaml:"compressionQueueSize,omitempty"`
+	// CompressionCommand and CompressionExt configure an external compressor
+	// (see WithCompressionCommand). Setting CompressionCommand implies Compress.
+	CompressionCommand []string `json:"compressionCommand,omitempty" yaml:"compressionCommand,omitempty"`
+	CompressionExt     string   `json:"compressionExt,omitempty" yaml:"compressionExt,omitempty"`
+
+	// Metadata writes a JSON sidecar per backup (see WithMetadata).
+	Metadata bool `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	// PostRotateCommand and PostRotateTimeout configure a command run after each rotation
+	// (see WithPostRotateCommand).
+	PostRotateCommand []string `json:"postRotateCommand,omitempty" yaml:"postRotateCommand,omitempty"`
+	PostRotateTimeout Duration `json:"postRotateTimeout,omitempty" yaml:"postRotateTimeout,omitempty"`
+
+	// Forward replays rotated files to a syslog or TCP endpoint (see WithForwarding).
+	Forward *ForwardConfig `json:"forward,omitempty" yaml:"forward,omitempty"`
+
+	// ErrorHandler handles asynchronous errors (see WithErrorHandler).
+	ErrorHandler func(error) `json:"-" yaml:"-"`
+	// LinePrefix is prepended to every written line (see WithLinePrefix).
+	LinePrefix func() []byte `json:"-" yaml:"-"`
+	// EventFunc receives typed events (see WithEventFunc).
+	EventFunc func(Event) `json:"-" yaml:"-"`
+	// NearLimitFunc is called once usage reaches NearLimitPercent of the maximum size or
+	// total budget (see WithNearLimitFunc).
+	NearLimitFunc    func(NearLimit) `json:"-" yaml:"-"`
+	NearLimitPercent int             `json:"nearLimitPercent,omitempty" yaml:"nearLimitPercent,omitempty"`
+	// RotationStream receives the content of every rotated file, which is deleted afterwards
+	// if DiscardStreamed is set (see WithRotationStream).
+	RotationStream  func(backupPath string, r io.Reader) error `json:"-" yaml:"-"`
+	DiscardStreamed bool                                       `json:"discardStreamed,omitempty" yaml:"discardStreamed,omitempty"`
+	// Tees receive a copy of every write (see WithTee).
+	Tees []io.Writer `json:"-" yaml:"-"`
+	// WriterWrappers decorate the active file, innermost first (see WithWriterWrapper).
+	WriterWrappers []func(io.Writer) io.WriteCloser `json:"-" yaml:"-"`
+}
+
+// NewWithOptions creates a new RollingFile like New, configured from a plain Options struct.
+// Additional functional options are applied after the struct and take precedence.
+func NewWithOptions(path string, opts Options, options ...Option) (*RollingFile, error) {
+	return New(path, append(opts.options(), options...)...)
+}
+
+// options converts the non-zero fields of o into functional options.
+func (o Options) options() []Option {
+	var options []Option
+	if o.MaxBytes != 0 {
+		options = append(options, WithMaxBytes(o.MaxBytes))
+	}
+	if o.MaxSize != "" {
+		options = append(options, WithMaxSize(o.MaxSize))
+	}
+	if o.RotationInterval != 0 {
+		options = append(options, WithRotationInterval(time.Duration(o.RotationInterval)))
+	}
+	if o.RotationJitter != 0 {
+		options = append(options, WithRotationJitter(time.Duration(o.RotationJitter)))
+	}
+	if o.ReadOnlyBackups {
+		options = append(options, WithReadOnlyBackups(o.ImmutableBackups))
+	}
+	if o.IdleRelease != 0 {
+		options = append(options, WithIdleRelease(time.Duration(o.IdleRelease)))
+	}
+	if o.RotationRateLimit != 0 {
+		options = append(options, WithRotationRateLimit(o.RotationRateLimit, time.Duration(o.RotationRateWindow)))
+	}
+	if o.RotationRetry != 0 {
+		options = append(options, WithRotationRetry(time.Duration(o.RotationRetry), o.RetryQueueBytes))
+	}
+	if o.TriggerFile {
+		options = append(options, WithTriggerFile(o.TriggerPath, time.Duration(o.TriggerInterval)))
+	}
+	if o.BackupDir != "" {
+		options = append(options, WithBackupDir(o.BackupDir))
+	}
+	if o.MaxBackups != 0 {
+		options = append(options, WithMaxBackups(o.MaxBackups))
+	}
+	if o.MaxUncompressedBackups != 0 {
+		options = append(options, WithMaxUncompressedBackups(o.MaxUncompressedBackups))
+	}
+	if o.MaxCompressedBackups != 0 {
+		options = append(options, WithMaxCompressedBackups(o.MaxCompressedBackups))
+	}
+	if o.MinBackups != 0 {
+		options = append(options, WithMinBackups(o.MinBackups))
+	}
+	if o.MaxAge != 0 {
+		options = append(options, WithMaxAge(time.Duration(o.MaxAge)))
+	}
+	if o.MaxDays != 0 {
+		options = append(options, WithMaxDays(o.MaxDays))
+	}
+	if o.HealthWindow != 0 {
+		options = append(options, WithHealthWindow(o.HealthWindow))
+	}
+	if o.CleanupBudget != 0 {
+		options = append(options, WithCleanupBudget(time.Duration(o.CleanupBudget)))
+	}
+	if o.MaxTotalBytes != 0 {
+		options = append(options, WithMaxTotalBytes(o.MaxTotalBytes))
+	}
+	if o.HardQuota {
+		options = append(options, WithHardQuota())
+	}
+	if o.DiskBudget != nil {
+		options = append(options, WithDiskBudget(o.DiskBudget))
+	}
+	if o.PersistentSequence {
+		options = append(options, WithPersistentSequence())
+	}
+	if o.Exclusive {
+		options = append(options, WithExclusive())
+	}
+	if o.LazyOpen {
+		options = append(options, WithLazyOpen())
+	}
+	if o.Preflight {
+		options = append(options, WithPreflight())
+	}
+	if o.Mmap {
+		options = append(options, WithMmap(o.MmapChunkSize, time.Duration(o.MmapSyncInterval)))
+	}
+	if o.DirectIO {
+		options = append(options, WithDirectIO(o.DirectBufferSize))
+	}
+	if o.OpenFlags != 0 {
+		options = append(options, WithOpenFlags(o.OpenFlags))
+	}
+	if o.Mode != 0 {
+		options = append(options, WithMode(o.Mode))
+	}
+	if o.StrictMode {
+		options = append(options, WithStrictMode())
+	}
+	if o.BackupMode != 0 {
+		options = append(options, WithBackupMode(o.BackupMode))
+	}
+	if o.LineEnding != 0 {
+		options = append(options, WithLineEnding(o.LineEnding))
+	}
+	if o.TruncateOversized {
+		options = append(options, WithTruncateOversized())
+	}
+	if o.RecoveryMarker {
+		options = append(options, WithRecoveryMarker())
+	}
+	if o.JSONLines {
+		options = append(options, WithJSONLines(o.QuarantinePath))
+	}
+	switch {
+	case o.JSONHeader != nil:
+		options = append(options, WithJSONHeader(o.JSONHeader))
+	case o.Header != "":
+		header := []byte(o.Header)
+		options = append(options, WithHeader(func() []byte { return header }))
+	}
+	if o.LengthPrefixedFrames {
+		options = append(options, WithLengthPrefixedFrames())
+	}
+	if o.FallbackPath != "" {
+		options = append(options, WithFallbackPath(o.FallbackPath))
+	}
+	if o.Compress {
+		options = append(options, WithCompression())
+	}
+	if o.CompressionWorkers != 0 || o.CompressionQueueSize != 0 {
+		options = append(options, WithCompressionWorkers(o.CompressionWorkers, o.CompressionQueueSize))
+	}
+	if len(o.CompressionCommand) > 0 {
+		options = append(options, WithCompressionCommand(o.CompressionExt, o.CompressionCommand[0], o.CompressionCommand[1:]...))
+	}
+	if o.Metadata {
+		options = append(options, WithMetadata())
+	}
+	if len(o.PostRotateCommand) > 0 {
+		options = append(options, WithPostRotateCommand(time.Duration(o.PostRotateTimeout), o.PostRotateCommand[0], o.PostRotateCommand[1:]...))
+	}
+	if o.Forward != nil {
+		options = append(options, WithForwarding(*o.Forward))
+	}
+	if o.ErrorHandler != nil {
+		options = append(options, WithErrorHandler(o.ErrorHandler))
+	}
+	if o.LinePrefix != nil {
+		options = append(options, WithLinePrefix(o.LinePrefix))
+	}
+	if o.EventFunc != nil {
+		options = append(options, WithEventFunc(o.EventFunc))
+	}
+	if o.NearLimitFunc != nil {
+		options = append(options, WithNearLimitFunc(o.NearLimitPercent, o.NearLimitFunc))
+	}
+	if o.RotationStream != nil {
+		options = append(options, WithRotationStream(o.RotationStream, o.DiscardStreamed))
+	}
+	for _, w := range o.Tees {
+		options = append(options, WithTee(w))
+	}
+	for _, wrap := range o.WriterWrappers {
+		options = append(options, WithWriterWrapper(wrap))
+	}
+	return options
+}
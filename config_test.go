@@ -0,0 +1,92 @@
+package rollingfile
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewWithOptionsMatchesFunctionalOptions verifies that the Options struct configures
+// a RollingFile the same way as the equivalent functional options.
+func TestNewWithOptionsMatchesFunctionalOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var opts Options
+	err := json.Unmarshal([]byte(`{
+		"maxSize": "1KiB",
+		"maxBackups": 5,
+		"minBackups": 2,
+		"maxAge": "1h",
+		"maxDays": 7,
+		"mode": 384
+	}`), &opts)
+	assert.NoError(t, err)
+
+	fromStruct, err := NewWithOptions(filepath.Join(tmpDir, "struct.log"), opts)
+	assert.NoError(t, err)
+	defer fromStruct.Close()
+
+	fromFuncs, err := New(filepath.Join(tmpDir, "funcs.log"),
+		WithMaxBytes(1024),
+		WithMaxBackups(5),
+		WithMinBackups(2),
+		WithMaxAge(time.Hour),
+		WithMaxDays(7),
+		WithMode(0600),
+	)
+	assert.NoError(t, err)
+	defer fromFuncs.Close()
+
+	assert.Equal(t, fromFuncs.maxSize, fromStruct.maxSize)
+	assert.Equal(t, fromFuncs.maxBackups, fromStruct.maxBackups)
+	assert.Equal(t, fromFuncs.minBackups, fromStruct.minBackups)
+	assert.Equal(t, fromFuncs.maxAge, fromStruct.maxAge)
+	assert.Equal(t, fromFuncs.maxDays, fromStruct.maxDays)
+	assert.Equal(t, fromFuncs.mode, fromStruct.mode)
+}
+
+// TestNewWithOptionsInvalidSize ensures that an invalid MaxSize is reported by NewWithOptions.
+func TestNewWithOptionsInvalidSize(t *testing.T) {
+	_, err := NewWithOptions(filepath.Join(t.TempDir(), "bad.log"), Options{MaxSize: "lots"})
+	assert.Error(t, err)
+}
+
+// TestNewWithOptionsHeader verifies that JSONHeader configures the header written to every file.
+func TestNewWithOptionsHeader(t *testing.T) {
+	var opts Options
+	err := json.Unmarshal([]byte(`{"jsonHeader": {"schema": "v1"}}`), &opts)
+	assert.NoError(t, err)
+
+	logger, err := NewWithOptions(filepath.Join(t.TempDir(), "header.log"), opts)
+	assert.NoError(t, err)
+	defer logger.Close()
+	assert.Equal(t, `{"schema":"v1"}`+"\n", string(logger.header()))
+}
+
+// TestNewWithOptionsLengthPrefixedFrames verifies that LengthPrefixedFrames is applied and validated like the option.
+func TestNewWithOptionsLengthPrefixedFrames(t *testing.T) {
+	logger, err := NewWithOptions(filepath.Join(t.TempDir(), "frames.log"), Options{LengthPrefixedFrames: true})
+	assert.NoError(t, err)
+	assert.True(t, logger.framed)
+	assert.NoError(t, logger.Close())
+
+	_, err = NewWithOptions(filepath.Join(t.TempDir(), "frames.log"), Options{LengthPrefixedFrames: true, JSONLines: true})
+	assert.Error(t, err)
+}
+
+// TestNewWithOptionsDiskBudget verifies that RollingFiles configured with the same DiskBudget share it.
+func TestNewWithOptionsDiskBudget(t *testing.T) {
+	tmpDir := t.TempDir()
+	opts := Options{DiskBudget: NewDiskBudget(1 << 20)}
+	for _, name := range []string{"a.log", "b.log"} {
+		logger, err := NewWithOptions(filepath.Join(tmpDir, name), opts)
+		assert.NoError(t, err)
+		defer logger.Close()
+		_, err = logger.Write([]byte("0123456789\n"))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int64(22), opts.DiskBudget.Usage())
+}
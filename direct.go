@@ -0,0 +1,147 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+const (
+	// directAlignment is the alignment of offsets, lengths and memory required for direct I/O.
+	// 4 KiB satisfies the logical block size of common devices.
+	directAlignment = 4096
+	// defaultDirectBuffer is the size of the aligned write buffer when WithDirectIO is given none.
+	defaultDirectBuffer = 1 << 20
+)
+
+// errDirectUnsupported is returned by openDirectFile on platforms without O_DIRECT.
+var errDirectUnsupported = errors.New("direct I/O not supported")
+
+// openDirect opens a second, direct I/O handle on the active file, if enabled, and loads the partial
+// last block of the file into the aligned buffer. If the platform or filesystem does not support
+// direct I/O, it is disabled and writes fall back to the normal path.
+func (l *RollingFile) openDirect() {
+	if l.directBuffer <= 0 || l.direct != nil {
+		return
+	}
+	if !l.onOSFS() {
+		l.disableDirect(errDirectUnsupported)
+		return
+	}
+	direct, err := openDirectFile(l.file.Name())
+	if err != nil {
+		l.disableDirect(err)
+		return
+	}
+	if l.directBuf == nil {
+		l.directBuf = alignedBuffer(l.directBuffer)
+	}
+	l.directOff = l.size - l.size%directAlignment
+	l.directLen = int(l.size - l.directOff)
+	l.directTail = l.directLen
+	if err := l.readActive(l.directBuf[:l.directLen], l.directOff); err != nil {
+		direct.Close()
+		l.disableDirect(err)
+		return
+	}
+	l.direct = direct
+}
+
+// disableDirect falls back to the normal write path for the rest of the RollingFile's lifetime.
+func (l *RollingFile) disableDirect(cause error) {
+	l.directBuffer = 0
+	l.directBuf = nil
+	if !errors.Is(cause, errDirectUnsupported) {
+		l.errorHandler(fmt.Errorf("falling back to regular writes, direct I/O failed: %w", cause))
+	}
+}
+
+// alignedBuffer returns a buffer of at least size bytes, rounded up to whole blocks, whose
+// start address is aligned for direct I/O.
+func alignedBuffer(size int) []byte {
+	size = (size + directAlignment - 1) / directAlignment * directAlignment
+	buf := make([]byte, size+directAlignment)
+	offset := 0
+	if rem := int(uintptr(unsafe.Pointer(&buf[0])) % directAlignment); rem != 0 {
+		offset = directAlignment - rem
+	}
+	return buf[offset : offset+size : offset+size]
+}
+
+// writeDirect appends data to the aligned buffer, writing it out whenever it is full.
+// If the filesystem rejects a direct write, the buffered data is written through the normal path instead.
+func (l *RollingFile) writeDirect(data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		n := copy(l.directBuf[l.directLen:], data[written:])
+		l.directLen += n
+		written += n
+		if l.directLen < len(l.directBuf) {
+			continue
+		}
+		if err := l.flushDirect(); err != nil {
+			return l.fallbackFromDirect(err, data, written)
+		}
+	}
+	return written, nil
+}
+
+// flushDirect writes the whole blocks of the buffer to the file with direct I/O and appends the rest of the
+// partial last block through the regular handle, so the file never contains padding. The partial last block
+// stays buffered, as it is rewritten by the next flush.
+func (l *RollingFile) flushDirect() error {
+	if l.directLen == 0 {
+		return nil
+	}
+	full := l.directLen - l.directLen%directAlignment
+	if full > 0 {
+		if _, err := l.direct.WriteAt(l.directBuf[:full], l.directOff); err != nil {
+			return err
+		}
+	}
+	// The direct write covers any earlier partial block, so the file now ends at the whole blocks.
+	start := full
+	if full == 0 {
+		start = l.directTail
+	}
+	if start < l.directLen {
+		if _, err := l.file.Write(l.directBuf[start:l.directLen]); err != nil {
+			return err
+		}
+	}
+	l.directTail = l.directLen - full
+	l.directLen = copy(l.directBuf, l.directBuf[full:l.directLen])
+	l.directOff += int64(full)
+	return nil
+}
+
+// fallbackFromDirect switches to the normal write path after a failed direct write. The buffered data,
+// which ends with the first written bytes of data, and the rest of data are appended to the file
+// through the regular handle. It returns the number of bytes of data written.
+func (l *RollingFile) fallbackFromDirect(cause error, data []byte, written int) (int, error) {
+	inBuffer := min(written, l.directLen)
+	earlier := l.directLen - inBuffer
+	pending := append(l.directBuf[:l.directLen:l.directLen], data[written:]...)
+	offset := l.directOff
+	l.direct.Close()
+	l.direct = nil
+	l.disableDirect(cause)
+	if err := l.file.Truncate(offset); err != nil {
+		return written - inBuffer, err
+	}
+	n, err := l.file.Write(pending)
+	return written - inBuffer + max(n-earlier, 0), err
+}
+
+// closeDirect flushes the buffer and closes the direct I/O handle, if any.
+func (l *RollingFile) closeDirect() error {
+	if l.direct == nil {
+		return nil
+	}
+	err := l.flushDirect()
+	if cerr := l.direct.Close(); err == nil {
+		err = cerr
+	}
+	l.direct = nil
+	return err
+}
@@ -0,0 +1,12 @@
+package rollingfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// openDirectFile opens path for writing with O_DIRECT, bypassing the page cache.
+// Filesystems without direct I/O support, such as tmpfs, fail with EINVAL.
+func openDirectFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|syscall.O_DIRECT, 0)
+}
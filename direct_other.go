@@ -0,0 +1,9 @@
+//go:build !linux
+
+package rollingfile
+
+import "os"
+
+func openDirectFile(string) (*os.File, error) {
+	return nil, errDirectUnsupported
+}
@@ -0,0 +1,97 @@
+//go:build linux
+
+package rollingfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newDirect creates a RollingFile with direct I/O, skipping the test if the filesystem rejects it.
+func newDirect(t *testing.T, logPath string, options ...Option) *RollingFile {
+	var errs []error
+	logger, err := New(logPath, append([]Option{
+		WithDirectIO(directAlignment),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }),
+	}, options...)...)
+	assert.NoError(t, err)
+	if logger.direct == nil {
+		logger.Close()
+		t.Skipf("direct I/O not supported here: %v", errs)
+	}
+	return logger
+}
+
+// TestDirectIOWritesExactContent verifies that buffered direct writes, spanning several blocks and
+// continuing a partial block of an existing file, produce the exact content.
+func TestDirectIOWritesExactContent(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "direct.log")
+	assert.NoError(t, os.WriteFile(logPath, []byte("existing\n"), 0644))
+	logger := newDirect(t, logPath)
+
+	line := strings.Repeat("d", 999) + "\n"
+	for i := 0; i < 10; i++ {
+		_, err := logger.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	expected := "existing\n" + strings.Repeat(line, 10)
+
+	r, err := logger.ReadCurrent()
+	assert.NoError(t, err)
+	current, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	r.Close()
+	assert.Equal(t, expected, string(current))
+
+	// Flushed partial blocks are appended without padding, so the file is exact at any time.
+	for _, tail := range []string{"", "next\n", "last\n"} {
+		_, err = logger.Write([]byte(tail))
+		assert.NoError(t, err)
+		expected += tail
+		assert.NoError(t, logger.Sync())
+		contents, err := os.ReadFile(logPath)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(contents))
+	}
+	assert.NoError(t, logger.Close())
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(contents))
+}
+
+// TestDirectIOFallsBack verifies that a failing direct write falls back to the normal path without losing data.
+func TestDirectIOFallsBack(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "fallback.log")
+	logger := newDirect(t, logPath)
+
+	_, err := logger.Write([]byte("buffered\n"))
+	assert.NoError(t, err)
+	// Make the next direct write fail.
+	logger.direct.Close()
+	logger.direct, err = os.Open(logPath)
+	assert.NoError(t, err)
+
+	data := strings.Repeat("x", directAlignment) + "\n"
+	n, err := logger.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	assert.Nil(t, logger.direct)
+	_, err = logger.Write([]byte("regular\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "buffered\n"+data+"regular\n", string(contents))
+}
+
+// TestDirectIOExcludesMmap verifies that direct I/O and mmap cannot be combined.
+func TestDirectIOExcludesMmap(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "both.log"), WithDirectIO(0), WithMmap(0, 0))
+	assert.Error(t, err)
+}
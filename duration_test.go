@@ -0,0 +1,62 @@
+package rollingfile
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDurationUnmarshalJSON verifies that durations are decoded from strings and numbers of nanoseconds.
+func TestDurationUnmarshalJSON(t *testing.T) {
+	cases := map[string]Duration{
+		`"1h"`:     Duration(time.Hour),
+		`"1h30m"`:  Duration(90 * time.Minute),
+		`"250ms"`:  Duration(250 * time.Millisecond),
+		`"0s"`:     0,
+		`60000000`: Duration(60 * time.Millisecond),
+	}
+	for input, expected := range cases {
+		var d Duration
+		assert.NoError(t, json.Unmarshal([]byte(input), &d), input)
+		assert.Equal(t, expected, d, input)
+	}
+
+	for _, input := range []string{`"hour"`, `"1x"`, `true`, `1.5`} {
+		var d Duration
+		assert.Error(t, json.Unmarshal([]byte(input), &d), input)
+	}
+}
+
+// TestDurationMarshalJSON verifies that durations round-trip through their string form.
+func TestDurationMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Options{MaxAge: Duration(36 * time.Hour)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"maxAge":"36h0m0s"}`, string(data))
+
+	var opts Options
+	assert.NoError(t, json.Unmarshal(data, &opts))
+	assert.Equal(t, Duration(36*time.Hour), opts.MaxAge)
+}
+
+// TestDurationUnmarshalYAML verifies that durations are decoded from YAML strings and integers.
+func TestDurationUnmarshalYAML(t *testing.T) {
+	decode := func(value any) func(any) error {
+		return func(v any) error {
+			data, _ := json.Marshal(value)
+			return json.Unmarshal(data, v)
+		}
+	}
+
+	var d Duration
+	assert.NoError(t, d.UnmarshalYAML(decode("2m")))
+	assert.Equal(t, Duration(2*time.Minute), d)
+	assert.NoError(t, d.UnmarshalYAML(decode(1000)))
+	assert.Equal(t, Duration(time.Microsecond), d)
+	assert.Error(t, d.UnmarshalYAML(decode("soon")))
+
+	value, err := Duration(time.Second).MarshalYAML()
+	assert.NoError(t, err)
+	assert.Equal(t, "1s", value)
+}
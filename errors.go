@@ -0,0 +1,61 @@
+package rollingfile
+
+import (
+	"sync"
+	"time"
+)
+
+// errorHistorySize is the number of recent errors kept for LastErrors.
+const errorHistorySize = 64
+
+// TimedError is an error passed to the error handler together with the time it occurred.
+type TimedError struct {
+	Time time.Time
+	Err  error
+}
+
+// errorHistory is a ring buffer of the most recent errors.
+type errorHistory struct {
+	mu     sync.Mutex
+	errors [errorHistorySize]TimedError
+	next   int
+	count  int
+}
+
+// add records err as occurred at t.
+func (h *errorHistory) add(t time.Time, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errors[h.next] = TimedError{Time: t, Err: err}
+	h.next = (h.next + 1) % errorHistorySize
+	h.count = min(h.count+1, errorHistorySize)
+}
+
+// last returns up to n of the most recent errors, oldest first.
+func (h *errorHistory) last(n int) []TimedError {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n = min(max(n, 0), h.count)
+	errs := make([]TimedError, n)
+	for i := range errs {
+		errs[i] = h.errors[(h.next-n+i+errorHistorySize)%errorHistorySize]
+	}
+	return errs
+}
+
+// recordErrors makes the error handler remember every error for LastErrors before handling it.
+func (l *RollingFile) recordErrors() {
+	handler := l.errorHandler
+	l.errorHandler = func(err error) {
+		l.errorHistory.add(l.now(), err)
+		handler(err)
+	}
+}
+
+// LastErrors returns up to n of the most recent errors passed to the error handler, such as errors
+// of cleanup, compression, post-rotate commands or forwarding, oldest first. Up to 64 errors are kept,
+// so problems remain inspectable when they occurred before a metrics scrape or while the error handler
+// was misconfigured.
+func (l *RollingFile) LastErrors(n int) []TimedError {
+	return l.errorHistory.last(n)
+}
@@ -0,0 +1,48 @@
+package rollingfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLastErrors verifies that the most recent errors are kept in order, with timestamps,
+// and still passed to the error handler.
+func TestLastErrors(t *testing.T) {
+	var handled int
+	logger, err := New(filepath.Join(t.TempDir(), "errors.log"), WithErrorHandler(func(error) { handled++ }))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+	assert.Empty(t, logger.LastErrors(10))
+
+	before := time.Now()
+	for i := 0; i < errorHistorySize+2; i++ {
+		logger.errorHandler(fmt.Errorf("error %d", i))
+	}
+	assert.Equal(t, errorHistorySize+2, handled)
+
+	last := logger.LastErrors(3)
+	if assert.Len(t, last, 3) {
+		assert.EqualError(t, last[0].Err, fmt.Sprintf("error %d", errorHistorySize-1))
+		assert.EqualError(t, last[2].Err, fmt.Sprintf("error %d", errorHistorySize+1))
+		assert.False(t, last[2].Time.Before(before))
+	}
+	assert.Len(t, logger.LastErrors(1000), errorHistorySize)
+	assert.Empty(t, logger.LastErrors(-1))
+}
+
+// TestLastErrorsUseClock verifies that errors are timestamped with the clock set by WithClock.
+func TestLastErrorsUseClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	logger, err := New(filepath.Join(t.TempDir(), "errors.log"), WithClock(func() time.Time { return now }))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	logger.errorHandler(fmt.Errorf("failed"))
+	if last := logger.LastErrors(1); assert.Len(t, last, 1) {
+		assert.Equal(t, now, last[0].Time)
+	}
+}
@@ -0,0 +1,97 @@
+package rollingfile
+
+import "time"
+
+// Event is implemented by all events passed to the function set with WithEventFunc:
+// RotationStarted, RotationCompleted, RotationThrottled, BackupDeleted, BackupCompressed, CleanupError
+// and CleanupIncomplete.
+type Event interface {
+	event()
+}
+
+// RotationStarted is emitted before the active file is rotated.
+type RotationStarted struct {
+	// Path is the path of the file being rotated.
+	Path string
+}
+
+// RotationCompleted is emitted after the active file has been renamed and reopened.
+type RotationCompleted struct {
+	// BackupPath is the path the rotated file was renamed to.
+	BackupPath string
+}
+
+// RotationThrottled is emitted when the rotation rate cap (see WithRotationRateLimit) engages and postpones
+// a rotation. It is emitted once until the next rotation.
+type RotationThrottled struct {
+	// Path is the path of the active file, which exceeds its limits until Until.
+	Path string
+	// Until is the earliest time of the next rotation.
+	Until time.Time
+}
+
+// BackupDeleted is emitted when a backup is deleted by cleanup.
+type BackupDeleted struct {
+	Path string
+}
+
+// BackupCompressed is emitted when a backup has been compressed.
+type BackupCompressed struct {
+	// Path is the path of the uncompressed backup, which no longer exists.
+	Path string
+	// CompressedPath is the path of the compressed backup.
+	CompressedPath string
+}
+
+// CleanupIncomplete is emitted when a cleanup run exceeded its time budget (see WithCleanupBudget).
+// The remaining work is resumed by the cleanup after the next rotation.
+type CleanupIncomplete struct {
+	Err error
+}
+
+// CleanupError is emitted for every error occurring during the cleanup of backup files.
+type CleanupError struct {
+	Err error
+}
+
+func (RotationStarted) event()   {}
+func (RotationCompleted) event() {}
+func (RotationThrottled) event() {}
+func (BackupDeleted) event()     {}
+func (BackupCompressed) event()  {}
+func (CleanupError) event()      {}
+func (CleanupIncomplete) event() {}
+
+// emit passes e to the event function, if any.
+func (l *RollingFile) emit(e Event) {
+	if l.eventFunc != nil {
+		l.eventFunc(e)
+	}
+}
+
+// queueEvent records e to be emitted once l.mu is released (see unlock), so that the event
+// function can call methods of the RollingFile. It must be called with l.mu held.
+func (l *RollingFile) queueEvent(e Event) {
+	if l.eventFunc != nil {
+		l.queuedEvents = append(l.queuedEvents, e)
+	}
+}
+
+// unlock releases l.mu and delivers the events and near-limit notifications queued while it was held.
+func (l *RollingFile) unlock() {
+	events, nearLimits := l.queuedEvents, l.queuedNearLimits
+	l.queuedEvents, l.queuedNearLimits = nil, nil
+	l.mu.Unlock()
+	for _, e := range events {
+		l.emit(e)
+	}
+	for _, n := range nearLimits {
+		l.nearLimitFunc(n)
+	}
+}
+
+// cleanupError reports an error occurring during cleanup to the error handler and as an event.
+func (l *RollingFile) cleanupError(err error) {
+	l.errorHandler(err)
+	l.emit(CleanupError{Err: err})
+}
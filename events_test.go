@@ -0,0 +1,86 @@
+package rollingfile
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEventsAreEmitted verifies that rotation, deletion and compression are reported as typed events.
+func TestEventsAreEmitted(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.log")
+	var mu sync.Mutex
+	counts := map[string]int{}
+	var completed []string
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMaxBackups(1),
+		WithCompression(),
+		WithEventFunc(func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch e := e.(type) {
+			case RotationStarted:
+				assert.Equal(t, logPath, e.Path)
+				counts["started"]++
+			case RotationCompleted:
+				completed = append(completed, e.BackupPath)
+			case BackupDeleted:
+				counts["deleted"]++
+			case BackupCompressed:
+				assert.Equal(t, e.Path+".gz", e.CompressedPath)
+				counts["compressed"]++
+			case CleanupError:
+				t.Errorf("unexpected cleanup error: %v", e.Err)
+			}
+		}),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("e", 80) + "\n"
+	for i := 0; i < 4; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, counts["started"])
+	assert.Len(t, completed, 3)
+	for _, backup := range completed {
+		assert.True(t, strings.HasPrefix(backup, logPath+"."))
+	}
+	assert.GreaterOrEqual(t, counts["deleted"], 1)
+	assert.GreaterOrEqual(t, counts["compressed"], 1)
+}
+
+// TestEventFuncCallsRollingFile verifies that the event function may call methods of the RollingFile
+// for events emitted during a write.
+func TestEventFuncCallsRollingFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "reentrant.log")
+	var logger *RollingFile
+	var names []string
+	logger, err := New(logPath,
+		WithMaxBytes(10),
+		WithEventFunc(func(e Event) {
+			switch e.(type) {
+			case RotationStarted, RotationCompleted:
+				names = append(names, logger.Name())
+				assert.NoError(t, logger.Healthy())
+			}
+		}),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = logger.Write([]byte("0123456\n"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, []string{logPath, logPath}, names)
+}
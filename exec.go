@@ -0,0 +1,43 @@
+package rollingfile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// runCommand runs cmd, capturing its stderr. A non-zero exit status is
+// reported together with whatever the command wrote to stderr.
+func runCommand(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// runPostRotateCommand runs the configured post-rotate command with backupPath
+// appended to its arguments, killing it once the timeout expires.
+func (l *RollingFile) runPostRotateCommand(backupPath string) error {
+	ctx := context.Background()
+	if l.postRotateTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.postRotateTimeout)
+		defer cancel()
+	}
+	args := append(append([]string{}, l.postRotateCommand[1:]...), backupPath)
+	cmd := exec.CommandContext(ctx, l.postRotateCommand[0], args...)
+	cmd.WaitDelay = time.Second
+	err := runCommand(cmd)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s: %w", l.postRotateTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPostRotateCommand verifies that the post-rotate command receives the backup path
+// before the backup is compressed.
+func TestPostRotateCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "hook.log")
+	outPath := filepath.Join(tmpDir, "hook.out")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithCompression(),
+		WithPostRotateCommand(5*time.Second, "sh", "-c", `cat "$1" > "$0"`, outPath),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("h", 80) + "\n"
+	for i := 0; i < 2; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(outPath)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, string(data))
+
+	files, err := filepath.Glob(logPath + ".*.gz")
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+// TestPostRotateCommandTimeout ensures that a hanging post-rotate command is killed
+// and reported to the error handler.
+func TestPostRotateCommandTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "timeout.log")
+	errs := make(chan error, 1)
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithPostRotateCommand(50*time.Millisecond, "sh", "-c", "exec sleep 10"),
+		WithErrorHandler(func(err error) { errs <- err }),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("h", 80) + "\n"
+	for i := 0; i < 2; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	start := time.Now()
+	err = logger.Close()
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	err = <-errs
+	assert.Contains(t, err.Error(), "timed out")
+}
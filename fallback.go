@@ -0,0 +1,146 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const defaultFallbackRetryInterval = time.Minute
+
+// openLogFile opens path for appending and returns the file together with its current size.
+// With WithStrictMode, a newly created file is set to the exact mode. When continuing a file this
+// RollingFile already wrote to (reopen), os.O_EXCL and os.O_TRUNC of WithOpenFlags are not applied.
+func (l *RollingFile) openLogFile(path string, reopen bool) (File, int64, error) {
+	flag := os.O_CREATE | os.O_APPEND | l.openFlags
+	if reopen {
+		flag &^= os.O_EXCL | os.O_TRUNC
+	}
+	if l.strictMode {
+		file, err := l.fs.OpenFile(path, flag|os.O_EXCL, l.mode)
+		if err == nil {
+			if err = l.applyStrictMode(file, l.mode); err != nil {
+				file.Close()
+				return nil, 0, err
+			}
+			return file, 0, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, 0, err
+		}
+	}
+	file, err := l.fs.OpenFile(path, flag, l.mode)
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, stat.Size(), nil
+}
+
+// applyStrictMode sets a newly created file to exactly mode with WithStrictMode, regardless of the umask.
+func (l *RollingFile) applyStrictMode(file File, mode os.FileMode) error {
+	if !l.strictMode {
+		return nil
+	}
+	if err := file.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set mode of %q: %w", file.Name(), err)
+	}
+	return nil
+}
+
+// openActive opens the active file, switching to the fallback path if it cannot be opened.
+// Only the first open applies os.O_EXCL and os.O_TRUNC (see WithOpenFlags); later ones,
+// after a release or a failed reopen, continue the file.
+func (l *RollingFile) openActive() error {
+	var err error
+	l.file, l.size, err = l.openLogFile(l.path, l.opened)
+	if err != nil {
+		if l.fallbackPath == "" {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		// failover sets up the fallback file.
+		if err = l.failover(err); err != nil {
+			return err
+		}
+	} else {
+		l.setupFile()
+	}
+	l.opened = true
+	if err = l.recoverLastByte(); err != nil {
+		l.closeFile()
+		l.file = nil
+		return fmt.Errorf("failed to read log file: %v", err)
+	}
+	return nil
+}
+
+// readActive reads len(buf) bytes at off from the active file. A write-only file (see WithOpenFlags)
+// is opened again for reading.
+func (l *RollingFile) readActive(buf []byte, off int64) error {
+	file := l.file
+	if l.openFlags&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		var err error
+		if file, err = l.open(l.file.Name()); err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+	_, err := file.ReadAt(buf, off)
+	return err
+}
+
+// failover switches writing to the fallback path after the primary path failed with cause.
+// A marker line noting the switch is written to the fallback file.
+func (l *RollingFile) failover(cause error) error {
+	file, size, err := l.openLogFile(l.fallbackPath, true)
+	if err != nil {
+		return fmt.Errorf("failed to open fallback log file: %w (after: %w)", err, cause)
+	}
+	if l.file != nil {
+		l.closeFile()
+	}
+	l.file = file
+	l.size = size
+	l.setupFile()
+	l.onFallback = true
+	l.lastFailbackAttempt = l.now()
+	l.errorHandler(fmt.Errorf("switched to fallback path %q: %w", l.fallbackPath, cause))
+	l.writeMarker(fmt.Sprintf("rollingfile: switched from %q to fallback path after error: %v\n", l.path, cause))
+	return nil
+}
+
+// tryFailback attempts to switch back to the primary path once the retry interval has elapsed.
+// A marker line noting the switch is written to the primary file.
+func (l *RollingFile) tryFailback() {
+	if l.now().Sub(l.lastFailbackAttempt) < l.fallbackRetryInterval {
+		return
+	}
+	l.lastFailbackAttempt = l.now()
+	file, size, err := l.openLogFile(l.path, true)
+	if err != nil {
+		return
+	}
+	l.closeFile()
+	l.file = file
+	l.size = size
+	l.setupFile()
+	l.onFallback = false
+	l.writeMarker(fmt.Sprintf("rollingfile: switched back from fallback path %q\n", l.fallbackPath))
+}
+
+// writeMarker writes an informational line to the current file, starting a new line if necessary.
+func (l *RollingFile) writeMarker(marker string) {
+	if l.size > 0 && l.lastByte != '\n' {
+		marker = "\n" + marker
+	}
+	if _, err := l.writeActive([]byte(marker)); err != nil {
+		l.errorHandler(fmt.Errorf("failed to write marker: %w", err))
+		return
+	}
+	l.lastByte = '\n'
+}
@@ -0,0 +1,79 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFallbackPathFailoverAndFailback verifies that writes continue on the fallback path
+// when the primary file fails and return to the primary path once it is writable again.
+func TestFallbackPathFailoverAndFailback(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "primary.log")
+	fallbackPath := filepath.Join(tmpDir, "fallback.log")
+	var errs []error
+	var broken atomic.Bool
+	now := time.Now()
+	logger, err := New(logPath,
+		WithFallbackPath(fallbackPath),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }),
+		WithFS(failingFS{fail: func(name string) bool { return name == logPath && broken.Load() }}),
+		WithClock(func() time.Time { return now }),
+	)
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte("one\n"))
+	assert.NoError(t, err)
+
+	// Make writes to the primary path fail, as on a read-only remount.
+	broken.Store(true)
+	_, err = logger.Write([]byte("two\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, fallbackPath, logger.Name())
+	assert.Len(t, errs, 1)
+
+	// Switch back on the next write once the primary path works and the retry interval has elapsed.
+	broken.Store(false)
+	now = now.Add(defaultFallbackRetryInterval)
+	_, err = logger.Write([]byte("three\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, logPath, logger.Name())
+
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	primary, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(primary)), "\n")
+	assert.Equal(t, "one", lines[0])
+	assert.Contains(t, lines[1], "switched back from fallback path")
+	assert.Equal(t, "three", lines[2])
+
+	fallback, err := os.ReadFile(fallbackPath)
+	assert.NoError(t, err)
+	lines = strings.Split(strings.TrimSpace(string(fallback)), "\n")
+	assert.Contains(t, lines[0], "to fallback path")
+	assert.Equal(t, "two", lines[1])
+}
+
+// TestFallbackPathAtStartup ensures that New falls back when the primary path cannot be opened.
+func TestFallbackPathAtStartup(t *testing.T) {
+	tmpDir := t.TempDir()
+	fallbackPath := filepath.Join(tmpDir, "fallback.log")
+	logger, err := New(filepath.Join(tmpDir, "missing", "primary.log"),
+		WithFallbackPath(fallbackPath),
+		WithErrorHandler(func(error) {}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, fallbackPath, logger.Name())
+	assert.NoError(t, logger.Close())
+
+	_, err = os.Stat(fallbackPath)
+	assert.NoError(t, err)
+}
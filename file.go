@@ -0,0 +1,135 @@
+package rollingfile
+
+import (
+	"fmt"
+	"io"
+)
+
+// setupFile prepares the newly opened active file for the configured write path
+// and wraps it with the configured writer decorators.
+func (l *RollingFile) setupFile() {
+	if err := l.trimPreallocated(); err != nil {
+		l.errorHandler(fmt.Errorf("failed to trim preallocated space of log file: %w", err))
+	}
+	l.mapFile()
+	l.openDirect()
+	var w io.Writer = fileWriter{l}
+	for _, wrap := range l.wrappers {
+		wc := wrap(w)
+		l.chain = append(l.chain, wc)
+		w = wc
+	}
+}
+
+// fileWriter writes to the active file through the configured write path, accounting its size.
+// It is the innermost writer of the decorator chain.
+type fileWriter struct {
+	l *RollingFile
+}
+
+func (w fileWriter) Write(p []byte) (int, error) {
+	n, err := w.l.writeFile(p)
+	w.l.size += int64(n)
+	return n, err
+}
+
+// writeActive writes data to the outermost writer decorator, or directly to the active file without decorators.
+func (l *RollingFile) writeActive(data []byte) (int, error) {
+	if len(l.chain) > 0 {
+		return l.chain[len(l.chain)-1].Write(data)
+	}
+	return fileWriter{l}.Write(data)
+}
+
+// writeFile writes data to the active file through the configured write path.
+func (l *RollingFile) writeFile(data []byte) (int, error) {
+	switch {
+	case l.mapping != nil:
+		return l.writeMapped(data)
+	case l.direct != nil:
+		return l.writeDirect(data)
+	}
+	return l.file.Write(data)
+}
+
+// closeFile closes the active file. The writer decorators are closed first, outermost first,
+// so they can write out what they buffered, then the write path is flushed and released.
+func (l *RollingFile) closeFile() error {
+	var err error
+	for i := len(l.chain) - 1; i >= 0; i-- {
+		if cerr := l.chain[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	l.chain = nil
+	if uerr := l.unmapFile(); err == nil {
+		err = uerr
+	}
+	if derr := l.closeDirect(); err == nil {
+		err = derr
+	}
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// flusher is implemented by writer decorators that buffer data, such as bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// flushFile writes data buffered by the writer decorators and the write path to the file,
+// so that it can be read.
+func (l *RollingFile) flushFile() error {
+	for i := len(l.chain) - 1; i >= 0; i-- {
+		if f, ok := l.chain[i].(flusher); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if l.direct != nil {
+		return l.flushDirect()
+	}
+	return nil
+}
+
+// syncFile flushes the writer decorators and the write path and commits the active file to stable storage.
+func (l *RollingFile) syncFile() error {
+	if err := l.flushFile(); err != nil {
+		return err
+	}
+	if l.mapping != nil {
+		if err := msyncFile(l.mapping, true); err != nil {
+			return err
+		}
+	}
+	return l.file.Sync()
+}
+
+// pendingHeader returns the header (see WithHeader) to write before the next write,
+// which is nil unless the active file is empty.
+func (l *RollingFile) pendingHeader() []byte {
+	if l.size != 0 || l.header == nil {
+		return nil
+	}
+	return l.header()
+}
+
+// checkHeaderFits returns an error if header and n bytes of data exceed the maximum size.
+func (l *RollingFile) checkHeaderFits(header []byte, n int) error {
+	if len(header) > 0 && int64(len(header)+n) > l.maxSize && l.maxSize > 0 {
+		return fmt.Errorf("line and header exceed max size")
+	}
+	return nil
+}
+
+// writeHeader writes header to the empty active file.
+func (l *RollingFile) writeHeader(header []byte) error {
+	if _, err := l.writeActive(header); err != nil {
+		return err
+	}
+	l.lastByte = header[len(header)-1]
+	return nil
+}
@@ -0,0 +1,77 @@
+package rollingfile
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w      io.Writer
+	n      *int
+	closed *[]string
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += n
+	return n, err
+}
+
+func (c countingWriter) Close() error {
+	*c.closed = append(*c.closed, "counter")
+	return nil
+}
+
+// upperWriter buffers and upper-cases everything written through it.
+type upperWriter struct {
+	*bufio.Writer
+	closed *[]string
+}
+
+func (u upperWriter) Write(p []byte) (int, error) {
+	return u.Writer.Write([]byte(strings.ToUpper(string(p))))
+}
+
+func (u upperWriter) Close() error {
+	*u.closed = append(*u.closed, "upper")
+	return u.Flush()
+}
+
+// TestWriterWrappers verifies that decorators are re-applied after rotation, closed outermost first
+// so buffered data reaches every file, and flushed by Sync.
+func TestWriterWrappers(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "wrapped.log")
+	var counted int
+	var closed []string
+	logger, err := New(logPath,
+		WithMaxBytes(10),
+		WithWriterWrapper(func(w io.Writer) io.WriteCloser { return countingWriter{w, &counted, &closed} }),
+		WithWriterWrapper(func(w io.Writer) io.WriteCloser { return upperWriter{bufio.NewWriter(w), &closed} }),
+	)
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Sync())
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "FIRST\n", string(contents))
+
+	_, err = logger.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"upper", "counter"}, closed)
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, []string{"upper", "counter", "upper", "counter"}, closed)
+	assert.Equal(t, 13, counted)
+
+	contents, err = os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "SECOND\n", string(contents))
+}
@@ -0,0 +1,201 @@
+package rollingfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ForwardFormat is the wire format used to forward rotated lines.
+type ForwardFormat string
+
+const (
+	// ForwardRFC5424 sends every line as an RFC 5424 syslog message. Over TCP, messages are
+	// framed by octet counting (RFC 6587).
+	ForwardRFC5424 ForwardFormat = "rfc5424"
+	// ForwardRaw sends every line unchanged, terminated by a newline.
+	ForwardRaw ForwardFormat = "raw"
+)
+
+const (
+	defaultForwardTimeout       = 10 * time.Second
+	defaultForwardRetryInterval = time.Second
+	syslogFacilityUser          = 1
+	syslogSeverityInfo          = 6
+	syslogTimeLayout            = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// ForwardConfig configures the forwarding of rotated files to a syslog or plain TCP endpoint (see WithForwarding).
+type ForwardConfig struct {
+	// Network is "tcp" (default) or "udp".
+	Network string `json:"network,omitempty" yaml:"network,omitempty"`
+	// Address is the host:port of the endpoint.
+	Address string `json:"address" yaml:"address"`
+	// Format is the wire format, ForwardRFC5424 by default.
+	Format ForwardFormat `json:"format,omitempty" yaml:"format,omitempty"`
+	// AppName is the syslog APP-NAME, the program name by default.
+	AppName string `json:"appName,omitempty" yaml:"appName,omitempty"`
+	// Facility and Severity form the syslog priority. If nil, they default to user (1) and informational (6);
+	// they are pointers so that kern and emerg (both 0) can be set.
+	Facility *int `json:"facility,omitempty" yaml:"facility,omitempty"`
+	Severity *int `json:"severity,omitempty" yaml:"severity,omitempty"`
+	// RateLimit is the maximum number of lines sent per second. Zero is unlimited.
+	RateLimit int `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+	// Retries is the number of times a failed connection or send is retried before forwarding
+	// of the file is given up, waiting RetryInterval (default 1s) in between.
+	Retries       int      `json:"retries,omitempty" yaml:"retries,omitempty"`
+	RetryInterval Duration `json:"retryInterval,omitempty" yaml:"retryInterval,omitempty"`
+	// Timeout bounds connecting and every send, 10s by default.
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// withDefaults validates c and fills in its defaults.
+func (c ForwardConfig) withDefaults() (ForwardConfig, error) {
+	if c.Address == "" {
+		return c, errors.New("forwarding address is required")
+	}
+	switch c.Network {
+	case "":
+		c.Network = "tcp"
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return c, fmt.Errorf("unsupported forwarding network %q", c.Network)
+	}
+	switch c.Format {
+	case "":
+		c.Format = ForwardRFC5424
+	case ForwardRFC5424, ForwardRaw:
+	default:
+		return c, fmt.Errorf("unsupported forwarding format %q", c.Format)
+	}
+	facility, severity := syslogFacilityUser, syslogSeverityInfo
+	if c.Facility != nil {
+		facility = *c.Facility
+	}
+	if c.Severity != nil {
+		severity = *c.Severity
+	}
+	if facility < 0 || facility > 23 || severity < 0 || severity > 7 {
+		return c, fmt.Errorf("invalid syslog facility %d or severity %d", facility, severity)
+	}
+	// Copied, so that later changes by the caller have no effect.
+	c.Facility, c.Severity = &facility, &severity
+	if c.AppName == "" {
+		c.AppName = filepath.Base(os.Args[0])
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = Duration(defaultForwardRetryInterval)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = Duration(defaultForwardTimeout)
+	}
+	return c, nil
+}
+
+// forwarder sends lines to the configured endpoint, reconnecting on failure.
+type forwarder struct {
+	cfg      ForwardConfig
+	hostname string
+	pid      string
+	conn     net.Conn
+	next     time.Time
+}
+
+// forwardBackup replays the lines of backupPath to the forwarding endpoint.
+func (l *RollingFile) forwardBackup(backupPath string) error {
+	file, err := l.open(backupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Already removed by cleanup.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	f := &forwarder{cfg: *l.forward, hostname: hostname, pid: strconv.Itoa(os.Getpid())}
+	defer f.close()
+
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimRight(line, "\r\n"); len(line) > 0 {
+			if err := f.send(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// send sends a single line, honoring the rate limit and retrying failures.
+func (f *forwarder) send(line []byte) error {
+	if f.cfg.RateLimit > 0 {
+		now := time.Now()
+		if f.next.After(now) {
+			time.Sleep(f.next.Sub(now))
+		} else {
+			f.next = now
+		}
+		f.next = f.next.Add(time.Second / time.Duration(f.cfg.RateLimit))
+	}
+
+	msg := f.format(line)
+	var err error
+	for attempt := 0; attempt <= f.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(f.cfg.RetryInterval))
+		}
+		if f.conn == nil {
+			f.conn, err = net.DialTimeout(f.cfg.Network, f.cfg.Address, time.Duration(f.cfg.Timeout))
+			if err != nil {
+				continue
+			}
+		}
+		f.conn.SetWriteDeadline(time.Now().Add(time.Duration(f.cfg.Timeout)))
+		if _, err = f.conn.Write(msg); err == nil {
+			return nil
+		}
+		f.close()
+	}
+	return fmt.Errorf("failed to forward to %s after %d attempts: %w", f.cfg.Address, f.cfg.Retries+1, err)
+}
+
+// format encodes line in the configured wire format.
+func (f *forwarder) format(line []byte) []byte {
+	if f.cfg.Format == ForwardRaw {
+		return append(line[:len(line):len(line)], '\n')
+	}
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	msg := fmt.Appendf(nil, "<%d>1 %s %s %s %s - - %s",
+		*f.cfg.Facility*8+*f.cfg.Severity, time.Now().Format(syslogTimeLayout), f.hostname, f.cfg.AppName, f.pid, line)
+	if strings.HasPrefix(f.cfg.Network, "tcp") {
+		msg = append(strconv.AppendInt(nil, int64(len(msg)), 10), append([]byte(" "), msg...)...)
+	}
+	return msg
+}
+
+// close closes the connection, if any.
+func (f *forwarder) close() {
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+}
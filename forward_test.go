@@ -0,0 +1,118 @@
+package rollingfile
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestForwardingSendsRFC5424 verifies that the lines of rotated files are sent to a TCP endpoint
+// as octet-counted RFC 5424 messages.
+func TestForwardingSendsRFC5424(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := bufio.NewReader(conn).ReadString(0)
+		received <- data
+	}()
+
+	logPath := filepath.Join(t.TempDir(), "fwd.log")
+	logger, err := New(logPath,
+		WithMaxBytes(13),
+		WithForwarding(ForwardConfig{Address: ln.Addr().String(), AppName: "app", RateLimit: 1000}),
+	)
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("first\nsecond\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("third\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	select {
+	case data := <-received:
+		message := regexp.MustCompile(`^<14>1 \S+ \S+ app \d+ - - (.*)$`)
+		var lines []string
+		for data != "" {
+			length, rest, ok := strings.Cut(data, " ")
+			n, err := strconv.Atoi(length)
+			if !ok || err != nil || n > len(rest) {
+				t.Fatalf("invalid frame %q", data)
+			}
+			m := message.FindStringSubmatch(rest[:n])
+			if assert.NotNil(t, m, "unexpected message %q", rest[:n]) {
+				lines = append(lines, m[1])
+			}
+			data = rest[n:]
+		}
+		assert.Equal(t, []string{"first", "second"}, lines)
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing forwarded")
+	}
+}
+
+// TestForwardingRetriesAndReports verifies that unreachable endpoints are retried and then reported.
+func TestForwardingRetriesAndReports(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var errs []error
+	logPath := filepath.Join(t.TempDir(), "fwd.log")
+	logger, err := New(logPath,
+		WithMaxBytes(7),
+		WithForwarding(ForwardConfig{Address: addr, Format: ForwardRaw, Retries: 2, RetryInterval: Duration(time.Millisecond)}),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }),
+	)
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	if assert.Len(t, errs, 1) {
+		assert.True(t, strings.Contains(errs[0].Error(), "after 3 attempts"), errs[0].Error())
+	}
+}
+
+// TestForwardingPriority verifies that the syslog priority defaults to user.info and that
+// kern and emerg, which are both 0, can be set.
+func TestForwardingPriority(t *testing.T) {
+	cfg, err := ForwardConfig{Address: "localhost:514"}.withDefaults()
+	assert.NoError(t, err)
+	f := &forwarder{cfg: cfg, hostname: "host", pid: "1"}
+	assert.Contains(t, string(f.format([]byte("line"))), "<14>1 ")
+
+	zero := 0
+	cfg, err = ForwardConfig{Address: "localhost:514", Facility: &zero, Severity: &zero}.withDefaults()
+	assert.NoError(t, err)
+	f = &forwarder{cfg: cfg, hostname: "host", pid: "1"}
+	assert.Contains(t, string(f.format([]byte("line"))), "<0>1 ")
+}
+
+// TestForwardingRejectsInvalidConfig verifies that New fails for invalid forwarding configurations.
+func TestForwardingRejectsInvalidConfig(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "fwd.log")
+	_, err := New(logPath, WithForwarding(ForwardConfig{}))
+	assert.Error(t, err)
+	_, err = New(logPath, WithForwarding(ForwardConfig{Address: "localhost:514", Format: "gelf"}))
+	assert.Error(t, err)
+	severity := 8
+	_, err = New(logPath, WithForwarding(ForwardConfig{Address: "localhost:514", Severity: &severity}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package rollingfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxFrameSize bounds the length of a frame accepted by FrameReader, so a corrupted length
+// prefix cannot cause a huge allocation.
+const maxFrameSize = 1 << 30
+
+// ErrInvalidFrame is returned by FrameReader for a truncated or corrupted frame, e.g. a partial
+// last record left behind by a crash.
+var ErrInvalidFrame = errors.New("invalid frame")
+
+// appendFrame appends record prefixed with its length as an unsigned varint to dst.
+func appendFrame(dst, record []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(record)))
+	return append(dst, record...)
+}
+
+// FrameReader reads the records of a file written with WithLengthPrefixedFrames.
+type FrameReader struct {
+	br  *bufio.Reader
+	buf []byte
+}
+
+// NewFrameReader returns a FrameReader reading from r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{br: bufio.NewReader(r)}
+}
+
+// Next returns the next record. The record is only valid until the next call to Next.
+// It returns io.EOF after the last record, and an error wrapping ErrInvalidFrame if the
+// input ends within a record or a length prefix is corrupted.
+func (r *FrameReader) Next() ([]byte, error) {
+	size, err := binary.ReadUvarint(r.br)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad length prefix: %v", ErrInvalidFrame, err)
+	}
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("%w: length %d exceeds limit", ErrInvalidFrame, size)
+	}
+	if uint64(cap(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+	if _, err := io.ReadFull(r.br, r.buf); err != nil {
+		return nil, fmt.Errorf("%w: truncated record of %d bytes: %v", ErrInvalidFrame, size, err)
+	}
+	return r.buf, nil
+}
+
+// ReadFrames calls fn for every record of the log at path written with WithLengthPrefixedFrames,
+// reading its backups, oldest first, followed by the active file. Gzip backups are decompressed
+// on the fly; backups compressed by an external command make ReadFrames fail. The record is only
+// valid during the call; an error returned by fn ends the iteration and is returned by ReadFrames.
+func ReadFrames(path string, fn func(file string, record []byte) error) error {
+	files, err := logSetFiles(path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := readFramesOf(osFS{}, file, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFramesOf calls fn for every record of a single file of a log set.
+func readFramesOf(fsys FS, file string, fn func(file string, record []byte) error) error {
+	rc, err := openForReading(fsys, file)
+	if errors.Is(err, os.ErrNotExist) && !strings.HasSuffix(file, compressedSuffix) {
+		// Compressed in the meantime.
+		file += compressedSuffix
+		rc, err = openForReading(fsys, file)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		// Removed by cleanup in the meantime.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	r := NewFrameReader(rc)
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if err := fn(file, record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package rollingfile
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLengthPrefixedFrames verifies that binary records survive rotation and compression intact
+// and are read back in order.
+func TestLengthPrefixedFrames(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.bin")
+	logger, err := New(logPath, WithMaxBytes(64), WithLengthPrefixedFrames(), WithCompression())
+	assert.NoError(t, err)
+
+	var records [][]byte
+	for i := 0; i < 20; i++ {
+		record := []byte{byte(i), '\n', 0, '\r', 0xff, byte(i)}
+		records = append(records, record)
+		n, err := logger.Write(record)
+		assert.NoError(t, err)
+		assert.Equal(t, len(record), n)
+	}
+	assert.NoError(t, logger.Close())
+
+	var read [][]byte
+	files := map[string]bool{}
+	err = ReadFrames(logPath, func(file string, record []byte) error {
+		files[file] = true
+		read = append(read, bytes.Clone(record))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, records, read)
+	assert.Greater(t, len(files), 1)
+}
+
+// TestFrameReaderTruncated verifies that a partial last record is reported.
+func TestFrameReaderTruncated(t *testing.T) {
+	data := appendFrame(appendFrame(nil, []byte("first")), []byte("second"))
+	r := NewFrameReader(bytes.NewReader(data[:len(data)-2]))
+
+	record, err := r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(record))
+	_, err = r.Next()
+	assert.ErrorIs(t, err, ErrInvalidFrame)
+
+	r = NewFrameReader(bytes.NewReader(data))
+	r.Next()
+	r.Next()
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+// TestLengthPrefixedFramesRejectLineOptions verifies that line-oriented options are rejected.
+func TestLengthPrefixedFramesRejectLineOptions(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "events.bin"), WithLengthPrefixedFrames(), WithLineEnding(LineEndingLF))
+	assert.ErrorContains(t, err, "line-oriented")
+}
+
+// TestLengthPrefixedFramesRecordTooLarge verifies that the length prefix counts towards the maximum size.
+func TestLengthPrefixedFramesRecordTooLarge(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.bin")
+	logger, err := New(logPath, WithMaxBytes(8), WithLengthPrefixedFrames())
+	assert.NoError(t, err)
+	defer logger.Close()
+	_, err = logger.Write(make([]byte, 8))
+	assert.Error(t, err)
+	_, err = logger.Write(make([]byte, 7))
+	assert.NoError(t, err)
+	info, err := os.Stat(logPath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8, info.Size())
+}
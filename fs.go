@@ -0,0 +1,104 @@
+package rollingfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FS is the filesystem a RollingFile keeps the active file, its backups and its sidecar files on (see WithFS).
+// Names are the paths given to New and the options, or derived from them with path/filepath.
+type FS interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	// Lstat is like Stat, but does not follow a symbolic link named name.
+	Lstat(name string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	Chmod(name string, mode os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	// Glob returns the names of all files matching pattern, like filepath.Glob.
+	Glob(pattern string) ([]string, error)
+}
+
+// File is a file opened by an FS. *os.File implements it.
+//
+// The os.FileInfo returned by Stat identifies the file through Sys, so that a renamed or replaced file can
+// be detected: files of the same FS are the same if os.SameFile reports so or their Sys values, which must
+// be comparable such as pointers, are equal.
+type File interface {
+	io.ReadWriteCloser
+	io.ReaderAt
+	io.WriterAt
+	Name() string
+	Stat() (os.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
+	Chmod(mode os.FileMode) error
+}
+
+// osFS is the FS of the operating system, used unless WithFS is given.
+type osFS struct{}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	file, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) Lstat(name string) (os.FileInfo, error)       { return os.Lstat(name) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Glob(pattern string) ([]string, error)        { return filepath.Glob(pattern) }
+
+// errOSFSRequired is returned by New for options that only work on the filesystem of the operating system.
+var errOSFSRequired = errors.New("requires the operating system's filesystem")
+
+// onOSFS reports whether the RollingFile works on the filesystem of the operating system.
+func (l *RollingFile) onOSFS() bool {
+	_, ok := l.fs.(osFS)
+	return ok
+}
+
+// open opens name of the RollingFile's filesystem for reading.
+func (l *RollingFile) open(name string) (File, error) {
+	return l.fs.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// readFile reads the whole file name of the RollingFile's filesystem.
+func (l *RollingFile) readFile(name string) ([]byte, error) {
+	file, err := l.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// createFile writes data to the file name of the RollingFile's filesystem, creating or truncating it.
+func (l *RollingFile) createFile(name string, data []byte, perm os.FileMode) error {
+	file, err := l.fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// sameFile reports whether the file infos a and b, returned by the same FS, describe the same file.
+func sameFile(a, b os.FileInfo) bool {
+	if os.SameFile(a, b) {
+		return true
+	}
+	sys := a.Sys()
+	return sys != nil && sys == b.Sys()
+}
@@ -0,0 +1,92 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// defaultHealthWindow is the number of writes and of rotations Healthy considers without WithHealthWindow.
+const defaultHealthWindow = 1
+
+// healthWindow holds the results of the last writes or rotations (see WithHealthWindow).
+type healthWindow struct {
+	errs []error
+	next int
+	full bool
+}
+
+// record adds a result, evicting the oldest result if the window is full.
+func (w *healthWindow) record(err error) {
+	if len(w.errs) == 0 {
+		w.errs = make([]error, defaultHealthWindow)
+	}
+	w.errs[w.next] = err
+	w.next = (w.next + 1) % len(w.errs)
+	w.full = w.full || w.next == 0
+}
+
+// failure returns an error describing the failed results of op in the window, if any.
+func (w *healthWindow) failure(op string) error {
+	n := w.next
+	if w.full {
+		n = len(w.errs)
+	}
+	var last error
+	failed := 0
+	// Walk from the oldest to the newest result, so that last is the most recent failure.
+	for i := 0; i < n; i++ {
+		if err := w.errs[(w.next-n+i+len(w.errs))%len(w.errs)]; err != nil {
+			failed++
+			last = err
+		}
+	}
+	switch {
+	case failed == 0:
+		return nil
+	case n == 1:
+		return fmt.Errorf("last %s failed: %w", op, last)
+	default:
+		return fmt.Errorf("%d of the last %d %ss failed: %w", failed, n, op, last)
+	}
+}
+
+// Healthy reports whether the RollingFile is able to write. It returns an error if the last write or
+// rotation failed, or any of the last writes or rotations checked with WithHealthWindow. It also returns
+// an error if the file descriptor is no longer usable, the path no longer refers to the open file or is
+// not writable, or writes currently go to the fallback path. It is suitable for readiness and liveness checks.
+func (l *RollingFile) Healthy() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.writeHealth.failure("write"); err != nil {
+		return err
+	}
+	if err := l.rotationHealth.failure("rotation"); err != nil {
+		return err
+	}
+	if l.onFallback {
+		return fmt.Errorf("writing to fallback path %q", l.fallbackPath)
+	}
+
+	if l.file == nil {
+		// Not opened yet or released (see WithLazyOpen and WithIdleRelease).
+		return nil
+	}
+	fdInfo, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("file descriptor is not usable: %w", err)
+	}
+	pathInfo, err := l.fs.Stat(l.file.Name())
+	if err != nil {
+		return fmt.Errorf("log file path is not accessible: %w", err)
+	}
+	if !sameFile(fdInfo, pathInfo) {
+		return errors.New("log file path no longer refers to the open file")
+	}
+	probe, err := l.fs.OpenFile(l.file.Name(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("log file path is not writable: %w", err)
+	}
+	return probe.Close()
+}
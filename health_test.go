@@ -0,0 +1,97 @@
+package rollingfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingFS is the OS filesystem with files whose writes fail while fail reports true for their name.
+type failingFS struct {
+	osFS
+	fail func(name string) bool
+}
+
+func (f failingFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	file, err := f.osFS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return failingFile{File: file, fail: f.fail}, nil
+}
+
+type failingFile struct {
+	File
+	fail func(name string) bool
+}
+
+func (f failingFile) Write(p []byte) (int, error) {
+	if f.fail(f.Name()) {
+		return 0, errors.New("injected write failure")
+	}
+	return f.File.Write(p)
+}
+
+// failWhile returns an FS whose writes fail while failing is set.
+func failWhile(failing *atomic.Bool) FS {
+	return failingFS{fail: func(string) bool { return failing.Load() }}
+}
+
+// TestHealthy verifies that Healthy detects a removed path and a failed write.
+func TestHealthy(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "health.log")
+	var failing atomic.Bool
+	logger, err := New(logPath, WithFS(failWhile(&failing)))
+	require.NoError(t, err)
+	defer logger.Close()
+
+	_, err = logger.Write([]byte("ok\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Healthy())
+
+	failing.Store(true)
+	_, err = logger.Write([]byte("broken\n"))
+	assert.Error(t, err)
+	assert.ErrorContains(t, logger.Healthy(), "last write failed")
+
+	// With the default window, a successful write clears the failure.
+	failing.Store(false)
+	_, err = logger.Write([]byte("ok\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Healthy())
+
+	require.NoError(t, os.Remove(logPath))
+	assert.Error(t, logger.Healthy())
+}
+
+// TestHealthWindow verifies that a failure is reported until it leaves the window of recent results.
+func TestHealthWindow(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "window.log")
+	var failing atomic.Bool
+	logger, err := New(logPath, WithFS(failWhile(&failing)), WithHealthWindow(3))
+	require.NoError(t, err)
+	defer logger.Close()
+
+	failing.Store(true)
+	_, err = logger.Write([]byte("broken\n"))
+	assert.Error(t, err)
+	failing.Store(false)
+
+	_, err = logger.Write([]byte("ok\n"))
+	assert.NoError(t, err)
+	assert.ErrorContains(t, logger.Healthy(), "1 of the last 2 writes failed")
+	_, err = logger.Write([]byte("ok\n"))
+	assert.NoError(t, err)
+	assert.ErrorContains(t, logger.Healthy(), "1 of the last 3 writes failed")
+	_, err = logger.Write([]byte("ok\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Healthy())
+
+	_, err = New(logPath, WithHealthWindow(0))
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package rollingfile
+
+import (
+	"fmt"
+	"time"
+)
+
+// Release closes the file handle of the active file, flushing buffered data. The file is reopened
+// transparently by the next write. It allows keeping many rarely written RollingFiles without
+// holding a file descriptor for each of them (see also WithIdleRelease).
+func (l *RollingFile) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.release()
+}
+
+// releaseHandle releases the active file like Release and reports whether it is still open,
+// as it is on the fallback path.
+func (l *RollingFile) releaseHandle() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.release()
+	return l.file != nil, err
+}
+
+// release closes the active file, unless it is on the fallback path, which must stay open until failback.
+func (l *RollingFile) release() error {
+	if l.file == nil || l.onFallback {
+		return nil
+	}
+	err := l.closeFile()
+	l.file = nil
+	l.released = true
+	return err
+}
+
+// touchIdle records a write for the idle release (see WithIdleRelease), starting the idle timer on the first write.
+func (l *RollingFile) touchIdle() {
+	if l.idleRelease <= 0 {
+		return
+	}
+	l.lastActivity = l.now()
+	if l.idleTimer == nil {
+		l.idleTimer = time.AfterFunc(l.idleRelease, l.releaseIdle)
+	}
+}
+
+// releaseIdle releases the active file if it has not been written to for the idle period,
+// otherwise it checks again once the period since the last write has passed.
+func (l *RollingFile) releaseIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.idleTimer == nil {
+		// Closed.
+		return
+	}
+	if idle := l.now().Sub(l.lastActivity); idle < l.idleRelease {
+		l.idleTimer.Reset(l.idleRelease - idle)
+		return
+	}
+	if err := l.release(); err != nil {
+		l.errorHandler(fmt.Errorf("failed to release idle log file: %w", err))
+	}
+	l.idleTimer = nil
+}
+
+// stopIdle stops the idle timer.
+func (l *RollingFile) stopIdle() {
+	if l.idleTimer != nil {
+		l.idleTimer.Stop()
+		l.idleTimer = nil
+	}
+}
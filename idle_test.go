@@ -0,0 +1,100 @@
+package rollingfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIdleRelease verifies that the file handle is released after the idle period and reopened by the next write.
+func TestIdleRelease(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	logger, err := New(logPath, WithIdleRelease(20*time.Millisecond), WithMaxBytes(100))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		return logger.file == nil
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, logger.Healthy())
+
+	r, err := logger.ReadCurrent()
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", string(data))
+
+	_, err = logger.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Sync())
+	data, err = os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+}
+
+// TestIdleReleaseUsesClock verifies that the idle period is measured with the clock set by WithClock.
+func TestIdleReleaseUsesClock(t *testing.T) {
+	var now atomic.Int64
+	now.Store(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	logger, err := New(filepath.Join(t.TempDir(), "app.log"),
+		WithIdleRelease(5*time.Millisecond),
+		WithClock(func() time.Time { return time.Unix(0, now.Load()) }),
+	)
+	assert.NoError(t, err)
+	defer logger.Close()
+	released := func() bool {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		return logger.file == nil
+	}
+
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.Never(t, released, 50*time.Millisecond, 5*time.Millisecond)
+	now.Add(int64(time.Minute))
+	assert.Eventually(t, released, time.Second, 5*time.Millisecond)
+}
+
+// TestRelease verifies that an explicitly released file keeps its size accounting for rotation.
+func TestRelease(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	logger, err := New(logPath, WithMaxBytes(10))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Release())
+	assert.NoError(t, logger.Release())
+	_, err = logger.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, logger.Stats().Rotations)
+}
+
+// TestReleaseKeepsPartialLine verifies that reopening a released file continues a partial line
+// without a recovery marker.
+func TestReleaseKeepsPartialLine(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	logger, err := New(logPath, WithRecoveryMarker())
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("partial"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Release())
+	_, err = logger.Write([]byte(" line\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "partial line\n", string(data))
+}
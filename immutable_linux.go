@@ -0,0 +1,41 @@
+//go:build linux && (386 || amd64 || arm || arm64 || loong64 || riscv64 || s390x)
+
+package rollingfile
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// fsImmutableFlag is FS_IMMUTABLE_FL from linux/fs.h.
+	fsImmutableFlag = 0x10
+	// fsIocGetFlags and fsIocSetFlags are FS_IOC_GETFLAGS and FS_IOC_SETFLAGS, encoded as _IOR('f', 1, long)
+	// and _IOW('f', 2, long) for the architectures using the generic ioctl encoding.
+	fsIocGetFlags = 2<<30 | uintptr(unsafe.Sizeof(uintptr(0)))<<16 | 'f'<<8 | 1
+	fsIocSetFlags = 1<<30 | uintptr(unsafe.Sizeof(uintptr(0)))<<16 | 'f'<<8 | 2
+)
+
+// setImmutable sets or clears the immutable attribute of a file, which requires CAP_LINUX_IMMUTABLE.
+func setImmutable(path string, immutable bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// The kernel reads and writes the flags as an int, despite the encoded size.
+	var flags int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIocGetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		return &os.PathError{Op: "ioctl", Path: path, Err: errno}
+	}
+	if immutable {
+		flags |= fsImmutableFlag
+	} else {
+		flags &^= fsImmutableFlag
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIocSetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		return &os.PathError{Op: "ioctl", Path: path, Err: errno}
+	}
+	return nil
+}
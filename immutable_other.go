@@ -0,0 +1,7 @@
+//go:build !(linux && (386 || amd64 || arm || arm64 || loong64 || riscv64 || s390x))
+
+package rollingfile
+
+func setImmutable(string, bool) error {
+	return errImmutableUnsupported
+}
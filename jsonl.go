@@ -0,0 +1,49 @@
+package rollingfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrInvalidJSONLine is returned by Write in JSON Lines mode when the written
+// data is not a single JSON object terminated by a newline.
+var ErrInvalidJSONLine = errors.New("invalid JSON line")
+
+// validateJSONLine reports whether p is exactly one JSON object followed by a newline.
+func validateJSONLine(p []byte) error {
+	body, ok := bytes.CutSuffix(p, []byte("\n"))
+	if !ok {
+		return fmt.Errorf("%w: missing trailing newline", ErrInvalidJSONLine)
+	}
+	body = bytes.TrimSuffix(body, []byte("\r"))
+	if bytes.ContainsAny(body, "\r\n") {
+		return fmt.Errorf("%w: record spans multiple lines", ErrInvalidJSONLine)
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return fmt.Errorf("%w: record is not a JSON object", ErrInvalidJSONLine)
+	}
+	if !json.Valid(trimmed) {
+		return fmt.Errorf("%w: malformed JSON", ErrInvalidJSONLine)
+	}
+	return nil
+}
+
+// quarantine appends a rejected record to the quarantine file, opening it on first use.
+func (l *RollingFile) quarantine(p []byte) error {
+	if l.quarantineFile == nil {
+		f, err := l.fs.OpenFile(l.quarantinePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, l.mode)
+		if err != nil {
+			return fmt.Errorf("failed to open quarantine file: %w", err)
+		}
+		l.quarantineFile = f
+	}
+	if len(p) == 0 || p[len(p)-1] != '\n' {
+		p = append(p[:len(p):len(p)], '\n')
+	}
+	_, err := l.quarantineFile.Write(p)
+	return err
+}
@@ -0,0 +1,69 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestJSONLinesRejectsInvalidRecords verifies that only single-line JSON objects are written.
+func TestJSONLinesRejectsInvalidRecords(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "records.jsonl")
+	logger, err := New(logPath, WithJSONLines(""))
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte(`{"a":1}` + "\n"))
+	assert.NoError(t, err)
+
+	for _, invalid := range []string{
+		`{"a":1}`,
+		`{"a":` + "\n",
+		`[1,2]` + "\n",
+		`{"a":1}` + "\n" + `{"b":2}` + "\n",
+		"{\n}\n",
+	} {
+		n, err := logger.Write([]byte(invalid))
+		assert.ErrorIs(t, err, ErrInvalidJSONLine, invalid)
+		assert.Equal(t, 0, n)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`+"\n", string(contents))
+}
+
+// TestJSONLinesQuarantine ensures that invalid records are diverted to the quarantine file.
+func TestJSONLinesQuarantine(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "records.jsonl")
+	quarantinePath := filepath.Join(tmpDir, "records.bad")
+	logger, err := New(logPath, WithJSONLines(quarantinePath))
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte(`{"a":1}` + "\n"))
+	assert.NoError(t, err)
+	n, err := logger.Write([]byte("not json"))
+	assert.NoError(t, err)
+	assert.Equal(t, len("not json"), n)
+
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`+"\n", string(contents))
+
+	quarantined, err := os.ReadFile(quarantinePath)
+	assert.NoError(t, err)
+	assert.Equal(t, "not json\n", string(quarantined))
+}
+
+// TestJSONLinesRejectsLinePrefix ensures that New rejects a line prefix, which would make the records invalid JSON.
+func TestJSONLinesRejectsLinePrefix(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "records.log"), WithJSONLines(""), WithLinePrefix(func() []byte { return []byte("> ") }))
+	assert.ErrorContains(t, err, "WithLinePrefix")
+}
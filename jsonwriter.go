@@ -0,0 +1,38 @@
+package rollingfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// JSONWriter writes values of type T to a RollingFile as JSON Lines. Every record is written with a single
+// Write, so rotation always happens at record boundaries and no record is split across files.
+// Combine it with WithJSONHeader to start every file with a schema record.
+// It is safe for concurrent use.
+type JSONWriter[T any] struct {
+	rf  *RollingFile
+	mu  sync.Mutex
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// NewJSONWriter returns a JSONWriter writing to rf.
+func NewJSONWriter[T any](rf *RollingFile) *JSONWriter[T] {
+	w := &JSONWriter[T]{rf: rf}
+	w.enc = json.NewEncoder(&w.buf)
+	return w
+}
+
+// Encode marshals v and writes it as one line. Records larger than the maximum size are rejected
+// by the RollingFile, unless WithTruncateOversized is set, which cuts the record and breaks its JSON.
+func (w *JSONWriter[T]) Encode(v T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Reset()
+	if err := w.enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := w.rf.Write(w.buf.Bytes())
+	return err
+}
@@ -0,0 +1,85 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRecord struct {
+	ID      int    `json:"id"`
+	Message string `json:"message"`
+}
+
+// TestJSONWriterRotatesAtRecordBoundaries verifies that every file consists of complete records,
+// each preceded by the header.
+func TestJSONWriterRotatesAtRecordBoundaries(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "records.log")
+	logger, err := New(logPath, WithMaxBytes(100), WithJSONLines(""), WithJSONHeader(map[string]string{"schema": "v1"}))
+	assert.NoError(t, err)
+
+	w := NewJSONWriter[testRecord](logger)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, w.Encode(testRecord{ID: i, Message: "hello\nworld"}))
+	}
+	assert.NoError(t, logger.Close())
+
+	files, err := filepath.Glob(logPath + "*")
+	assert.NoError(t, err)
+	assert.Greater(t, len(files), 2)
+	var records int
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		lines := strings.SplitAfter(string(data), "\n")
+		assert.Equal(t, "", lines[len(lines)-1], "file %s ends mid-record", file)
+		assert.Equal(t, `{"schema":"v1"}`+"\n", lines[0], "file %s starts without header", file)
+		for _, line := range lines[1 : len(lines)-1] {
+			assert.NoError(t, validateJSONLine([]byte(line)))
+			records++
+		}
+	}
+	assert.Equal(t, 10, records)
+}
+
+// TestJSONHeaderInvalid verifies that a header that cannot be marshalled is rejected by New.
+func TestJSONHeaderInvalid(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "records.log"), WithJSONHeader(func() {}))
+	assert.ErrorContains(t, err, "invalid JSON header")
+}
+
+// TestHeaderCountsTowardsLimits verifies that the header is included in the maximum size and the hard quota.
+func TestHeaderCountsTowardsLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "header.log")
+	header := WithHeader(func() []byte { return []byte("# header\n") })
+	logger, err := New(logPath, WithMaxBytes(40), header)
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = logger.Write([]byte(strings.Repeat("h", 14) + "\n"))
+		assert.NoError(t, err)
+	}
+	_, err = logger.Write([]byte(strings.Repeat("h", 34) + "\n"))
+	assert.ErrorContains(t, err, "exceed max size")
+	assert.NoError(t, logger.Close())
+
+	files, err := filepath.Glob(logPath + "*")
+	assert.NoError(t, err)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(40), file)
+	}
+
+	quotaPath := filepath.Join(tmpDir, "quota.log")
+	logger, err = New(quotaPath, WithMaxTotalBytes(20), WithHardQuota(), header)
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte(strings.Repeat("q", 14) + "\n"))
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.NoError(t, logger.Close())
+}
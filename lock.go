@@ -0,0 +1,48 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrLocked is returned by New with WithExclusive when another instance already writes to the path.
+var ErrLocked = errors.New("log file is locked by another instance")
+
+// errLockUnsupported is returned by lockFile on platforms without advisory locks.
+var errLockUnsupported = errors.New("advisory locks not supported")
+
+// lockPath returns the path of the lock file of path. A separate file is locked because
+// the log file itself is renamed on rotation.
+func lockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+}
+
+// acquireLock takes the exclusive advisory lock of the log file, held until Close.
+func (l *RollingFile) acquireLock() error {
+	file, err := os.OpenFile(lockPath(l.path), os.O_CREATE|os.O_RDWR, l.mode)
+	if err != nil {
+		return err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, ErrLocked) {
+			return fmt.Errorf("%w: %s", ErrLocked, l.path)
+		}
+		return err
+	}
+	// Record the owner to ease diagnosing a refused start.
+	file.Truncate(0)
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	l.lockFile = file
+	return nil
+}
+
+// releaseLock releases the advisory lock, if held.
+func (l *RollingFile) releaseLock() {
+	if l.lockFile != nil {
+		l.lockFile.Close()
+		l.lockFile = nil
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+
+package rollingfile
+
+import "os"
+
+func lockFile(*os.File) error {
+	return errLockUnsupported
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+
+package rollingfile
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExclusiveRejectsSecondWriter verifies that a second exclusive instance fails fast and
+// that the lock is released on Close.
+func TestExclusiveRejectsSecondWriter(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "daemon.log")
+	first, err := New(logPath, WithExclusive())
+	assert.NoError(t, err)
+
+	_, err = New(logPath, WithExclusive())
+	assert.ErrorIs(t, err, ErrLocked)
+
+	assert.NoError(t, first.Close())
+	second, err := New(logPath, WithExclusive())
+	assert.NoError(t, err)
+	assert.NoError(t, second.Close())
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Empty(t, files, "the lock file must not be mistaken for a backup")
+}
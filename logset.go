@@ -0,0 +1,187 @@
+package rollingfile
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// logSetFiles returns the files of the log set at path in chronological order:
+// its backups, oldest first, followed by the active file if it exists.
+func logSetFiles(path string) ([]string, error) {
+	return (&RollingFile{path: path, fs: osFS{}}).logSetFiles()
+}
+
+// logSetFiles returns the files of the RollingFile's log set, see logSetFiles. Backups compressed
+// by an external command (see WithCompressionCommand) cannot be decompressed and are reported
+// as an error instead of being read as text.
+func (l *RollingFile) logSetFiles() ([]string, error) {
+	files, err := l.listBackups(l.path)
+	if err != nil {
+		return nil, err
+	}
+	prefix := l.backupPrefix(l.path)
+	for _, file := range files {
+		if isExternallyCompressed(prefix, file) {
+			return nil, fmt.Errorf("cannot read backup %q: compressed by an external command", file)
+		}
+	}
+	if _, err := l.fs.Stat(l.path); err == nil {
+		files = append(files, l.path)
+	}
+	return files, nil
+}
+
+// isExternallyCompressed reports whether file is a backup with the given prefix whose name carries
+// an extension other than that of gzip, as appended by WithCompressionCommand.
+func isExternallyCompressed(prefix, file string) bool {
+	rest, ok := strings.CutPrefix(file, prefix)
+	if !ok || backupNameRegexp.MatchString(rest) || backupNameRegexp.MatchString(strings.TrimSuffix(rest, compressedSuffix)) {
+		return false
+	}
+	i := strings.LastIndexByte(rest, '.')
+	return i > 0 && backupNameRegexp.MatchString(rest[:i])
+}
+
+// openForReading opens a log file of fsys for reading, transparently decompressing gzip backups.
+// An active file preallocated by WithMmap is only read up to its written size, not into the zero bytes
+// of the preallocated space.
+func openForReading(fsys FS, path string) (io.ReadCloser, error) {
+	file, err := fsys.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, compressedSuffix) {
+		rc, err := limitToWritten(fsys, file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return rc, nil
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("invalid gzip file %q: %w", path, err)
+	}
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// limitToWritten returns file, limited to its written size if it is preallocated.
+func limitToWritten(fsys FS, file File) (io.ReadCloser, error) {
+	written, ok, err := (&RollingFile{fs: fsys}).readPreallocMarker(file.Name())
+	if err != nil || !ok {
+		return file, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size, err := writtenSize(func(buf []byte, off int64) error {
+		_, err := file.ReadAt(buf, off)
+		return err
+	}, info.Size(), written)
+	if err != nil {
+		return nil, err
+	}
+	return &limitedFile{Reader: io.LimitReader(file, size), file: file}, nil
+}
+
+// limitedFile reads up to a limit and closes the underlying file.
+type limitedFile struct {
+	io.Reader
+	file File
+}
+
+func (f *limitedFile) Close() error {
+	return f.file.Close()
+}
+
+// gzipFile closes both the gzip reader and the underlying file.
+type gzipFile struct {
+	*gzip.Reader
+	file File
+}
+
+func (f *gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
+
+// lineReader reads the lines of a sequence of log files, one file after the other.
+type lineReader struct {
+	fs     FS
+	files  []string
+	rc     io.ReadCloser
+	br     *bufio.Reader
+	file   string
+	offset int64
+}
+
+// next returns the next line including its trailing newline, together with the file it was
+// read from and its offset within the (uncompressed) file. It returns io.EOF after the last line.
+func (r *lineReader) next() (file string, offset int64, line []byte, err error) {
+	for {
+		if r.br == nil {
+			if len(r.files) == 0 {
+				return "", 0, nil, io.EOF
+			}
+			r.file, r.files = r.files[0], r.files[1:]
+			r.offset = 0
+			rc, err := openForReading(r.fs, r.file)
+			if errors.Is(err, os.ErrNotExist) && !strings.HasSuffix(r.file, compressedSuffix) {
+				// Compressed in the meantime.
+				r.file += compressedSuffix
+				rc, err = openForReading(r.fs, r.file)
+			}
+			if errors.Is(err, os.ErrNotExist) {
+				// Removed by cleanup in the meantime.
+				continue
+			}
+			if err != nil {
+				return "", 0, nil, err
+			}
+			r.rc, r.br = rc, bufio.NewReader(rc)
+		}
+
+		line, err := r.br.ReadBytes('\n')
+		if len(line) > 0 {
+			offset := r.offset
+			r.offset += int64(len(line))
+			return r.file, offset, line, nil
+		}
+		if err != io.EOF {
+			return "", 0, nil, err
+		}
+		r.rc.Close()
+		r.rc, r.br = nil, nil
+	}
+}
+
+// close releases the currently open file, if any.
+func (r *lineReader) close() {
+	if r.rc != nil {
+		r.rc.Close()
+		r.rc, r.br = nil, nil
+	}
+}
+
+// lineTime returns the time of a line read from file. Lines without a timestamp inherit last, the time
+// of the previous line, so multi-line records such as stack traces stay together. Until the first
+// timestamp is seen, the start time recorded in the metadata sidecar of the file is used if available.
+func lineTime(timestamp TimestampFunc, file string, line []byte, last time.Time) time.Time {
+	if ts, ok := timestamp(line); ok {
+		return ts
+	}
+	if last.IsZero() {
+		if meta, err := readMetadataFor(file); err == nil {
+			return meta.Start
+		}
+	}
+	return last
+}
@@ -0,0 +1,175 @@
+package rollingfile
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var errManagerClosed = errors.New("manager is closed")
+
+// Manager manages one RollingFile per key, e.g. per tenant, created on first use. With a limit on open files,
+// it keeps an LRU of the files holding a file descriptor and releases the least recently used one (see Release)
+// when the limit is exceeded; released files are reopened on their next write.
+// It is safe for concurrent use.
+type Manager struct {
+	mu      sync.Mutex
+	path    func(key string) string
+	options []Option
+	maxOpen int
+	files   map[string]*managedFile
+	// lru holds the open files, most recently used first.
+	lru   *list.List
+	stats ManagerStats
+}
+
+type managedFile struct {
+	rf   *RollingFile
+	elem *list.Element
+	// pins counts the writes through Write in progress, during which the file is not evicted.
+	pins int
+}
+
+// ManagerStats is a snapshot of the LRU statistics of a Manager.
+type ManagerStats struct {
+	// Files is the number of managed files, Open the number of those not released by the LRU.
+	Files, Open int
+	// Hits counts uses of open files by Write and Get, Misses uses that created or reopened a file.
+	Hits, Misses int64
+	// Evictions counts the files released to stay within the limit. Files written on their fallback path
+	// (see WithFallbackPath) cannot be released and stay open.
+	Evictions int64
+}
+
+// NewManager returns a Manager creating the RollingFile of a key at path(key) with options.
+// At most maxOpen files are kept open if maxOpen is positive.
+func NewManager(path func(key string) string, maxOpen int, options ...Option) *Manager {
+	return &Manager{
+		path:    path,
+		options: options,
+		maxOpen: maxOpen,
+		files:   map[string]*managedFile{},
+		lru:     list.New(),
+	}
+}
+
+// Write writes p to the RollingFile of key, creating it on first use. The file is not evicted while
+// the write is in progress, so concurrent writes to other keys cannot make it reopen outside the LRU.
+func (m *Manager) Write(key string, p []byte) (int, error) {
+	m.mu.Lock()
+	f, err := m.use(key, true)
+	m.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.rf.Write(p)
+
+	m.mu.Lock()
+	f.pins--
+	if m.files != nil {
+		m.evict()
+	}
+	m.mu.Unlock()
+	return n, err
+}
+
+// Get returns the RollingFile of key, creating it on first use, and marks it as the most recently used.
+// Writes made directly to the returned RollingFile after it has been released by the Manager reopen it
+// without the Manager noticing, so the limit on open files is only kept for writes through Write.
+func (m *Manager) Get(key string) (*RollingFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := m.use(key, false)
+	if err != nil {
+		return nil, err
+	}
+	return f.rf, nil
+}
+
+// use returns the managed file of key, creating it on first use, and marks it as the most recently used.
+// With pin, it is pinned before other files are evicted. The caller must hold m.mu.
+func (m *Manager) use(key string, pin bool) (*managedFile, error) {
+	if m.files == nil {
+		return nil, errManagerClosed
+	}
+	f, ok := m.files[key]
+	switch {
+	case ok && f.elem != nil:
+		m.stats.Hits++
+		m.lru.MoveToFront(f.elem)
+		if pin {
+			f.pins++
+		}
+		return f, nil
+	case ok:
+		m.stats.Misses++
+	default:
+		m.stats.Misses++
+		rf, err := New(m.path(key), m.options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create log file for key %q: %w", key, err)
+		}
+		f = &managedFile{rf: rf}
+		m.files[key] = f
+	}
+	f.elem = m.lru.PushFront(key)
+	if pin {
+		f.pins++
+	}
+	m.evict()
+	return f, nil
+}
+
+// evict releases the least recently used files beyond the limit on open files. Files with writes in
+// progress are skipped; the limit is restored when those writes finish.
+func (m *Manager) evict() {
+	elem := m.lru.Back()
+	for m.maxOpen > 0 && m.lru.Len() > m.maxOpen && elem != nil {
+		prev := elem.Prev()
+		key := elem.Value.(string)
+		f := m.files[key]
+		if f.pins > 0 {
+			elem = prev
+			continue
+		}
+		open, err := f.rf.releaseHandle()
+		if err != nil {
+			f.rf.errorHandler(fmt.Errorf("failed to release log file: %w", err))
+		}
+		if open {
+			elem = prev
+			continue
+		}
+		m.lru.Remove(elem)
+		elem = prev
+		f.elem = nil
+		m.stats.Evictions++
+	}
+}
+
+// Stats returns the LRU statistics of the Manager.
+func (m *Manager) Stats() ManagerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Files = len(m.files)
+	stats.Open = m.lru.Len()
+	return stats
+}
+
+// Close closes all managed files. The Manager cannot be used afterwards.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []error
+	for key, f := range m.files {
+		if err := f.rf.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close log file for key %q: %w", key, err))
+		}
+	}
+	m.files = nil
+	m.lru.Init()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,125 @@
+package rollingfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManagerLRU verifies that the Manager keeps at most the configured number of files open,
+// releasing the least recently used ones and reopening them on demand.
+func TestManagerLRU(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(func(key string) string { return filepath.Join(tmpDir, key+".log") }, 2)
+
+	for _, key := range []string{"a", "b", "a", "c", "a", "b"} {
+		_, err := m.Write(key, []byte(key+"\n"))
+		assert.NoError(t, err)
+	}
+	// a miss, b miss, a hit, c miss (evicts b), a hit, b miss (evicts c).
+	assert.Equal(t, ManagerStats{Files: 3, Open: 2, Hits: 2, Misses: 4, Evictions: 2}, m.Stats())
+
+	_, err := m.Write("c", []byte("c\n"))
+	assert.NoError(t, err)
+	c := m.files["c"].rf
+	c.mu.Lock()
+	assert.NotNil(t, c.file, "c should have been reopened")
+	c.mu.Unlock()
+	a := m.files["a"].rf
+	a.mu.Lock()
+	assert.Nil(t, a.file, "a should have been released")
+	a.mu.Unlock()
+
+	assert.NoError(t, m.Close())
+	for key, expected := range map[string]string{"a": "a\na\na\n", "b": "b\nb\n", "c": "c\nc\n"} {
+		data, err := os.ReadFile(filepath.Join(tmpDir, key+".log"))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+	_, err = m.Write("a", []byte("a\n"))
+	assert.Error(t, err)
+}
+
+// TestManagerCreateError verifies that errors creating a file are returned and nothing is cached.
+func TestManagerCreateError(t *testing.T) {
+	m := NewManager(func(key string) string { return filepath.Join(t.TempDir(), key+".log") }, 0, WithMaxSize("invalid"))
+	_, err := m.Write("a", []byte("a\n"))
+	assert.ErrorContains(t, err, `key "a"`)
+	assert.Equal(t, 0, m.Stats().Files)
+}
+
+// TestManagerPinsWritingFile ensures that a file is not evicted while a write to it is in progress,
+// and that the limit is restored once it finishes.
+func TestManagerPinsWritingFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	started, proceed := make(chan struct{}), make(chan struct{})
+	block := WithWriterWrapper(func(w io.Writer) io.WriteCloser {
+		return blockingWriter{w, started, proceed}
+	})
+	m := NewManager(func(key string) string { return filepath.Join(tmpDir, key+".log") }, 1, block)
+
+	done := make(chan error)
+	go func() {
+		_, err := m.Write("a", []byte("block\n"))
+		done <- err
+	}()
+	<-started
+	_, err := m.Write("b", []byte("b\n"))
+	assert.NoError(t, err)
+	m.mu.Lock()
+	assert.NotNil(t, m.files["a"].elem, "a must stay open while it is written")
+	m.mu.Unlock()
+
+	close(proceed)
+	assert.NoError(t, <-done)
+	assert.Equal(t, ManagerStats{Files: 2, Open: 1, Misses: 2, Evictions: 1}, m.Stats())
+	for key, f := range m.files {
+		f.rf.mu.Lock()
+		assert.Equal(t, f.elem != nil, f.rf.file != nil, "%s must be open exactly while in the LRU", key)
+		f.rf.mu.Unlock()
+	}
+	assert.NoError(t, m.Close())
+}
+
+// blockingWriter signals started and waits for proceed before writing data containing "block".
+type blockingWriter struct {
+	io.Writer
+	started, proceed chan struct{}
+}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), "block") {
+		close(w.started)
+		<-w.proceed
+	}
+	return w.Writer.Write(p)
+}
+
+func (w blockingWriter) Close() error { return nil }
+
+// TestManagerKeepsFallbackFileOpen verifies that a file written on its fallback path, which Release
+// cannot close, is neither removed from the LRU nor counted as evicted, and other files are evicted instead.
+func TestManagerKeepsFallbackFileOpen(t *testing.T) {
+	tmpDir := t.TempDir()
+	aPath := filepath.Join(tmpDir, "a.log")
+	m := NewManager(func(key string) string { return filepath.Join(tmpDir, key+".log") }, 1,
+		WithFallbackPath(filepath.Join(tmpDir, "fallback.log")),
+		WithErrorHandler(func(error) {}),
+		WithFS(failingFS{fail: func(name string) bool { return name == aPath }}),
+	)
+	defer m.Close()
+
+	_, err := m.Write("a", []byte("a\n"))
+	assert.NoError(t, err)
+	_, err = m.Write("b", []byte("b\n"))
+	assert.NoError(t, err)
+	// b is evicted instead of a once its write has finished.
+	assert.Equal(t, ManagerStats{Files: 2, Open: 1, Misses: 2, Evictions: 1}, m.Stats())
+	assert.NotEqual(t, aPath, m.files["a"].rf.Name())
+	assert.NotNil(t, m.files["a"].elem)
+	assert.Nil(t, m.files["b"].elem)
+}
@@ -0,0 +1,110 @@
+package rollingfile
+
+import (
+	"bytes"
+	"container/heap"
+	"io"
+	"time"
+)
+
+// TimestampFunc extracts the timestamp of a log line. It returns false if the line carries no timestamp.
+type TimestampFunc func(line []byte) (time.Time, bool)
+
+// RFC3339Timestamp is a TimestampFunc for lines starting with an RFC 3339 timestamp,
+// such as those written by WithLinePrefix(func() []byte { return []byte(time.Now().Format(time.RFC3339Nano) + " ") }).
+func RFC3339Timestamp(line []byte) (time.Time, bool) {
+	field, _, _ := bytes.Cut(line, []byte(" "))
+	ts, err := time.Parse(time.RFC3339Nano, string(bytes.TrimSpace(field)))
+	return ts, err == nil
+}
+
+// mergeSource is the next pending line of one log set.
+type mergeSource struct {
+	index  int
+	reader *lineReader
+	time   time.Time
+	line   []byte
+}
+
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if !h[i].time.Equal(h[j].time) {
+		return h[i].time.Before(h[j].time)
+	}
+	return h[i].index < h[j].index
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// advance reads the next line of the source. It reports false once the source is exhausted.
+func (s *mergeSource) advance(timestamp TimestampFunc) (bool, error) {
+	file, _, line, err := s.reader.next()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s.time, s.line = lineTime(timestamp, file, line, s.time), line
+	return true, nil
+}
+
+// Merge writes the lines of several log sets (the active file and backups of each path, as written
+// by separate RollingFiles or instances) to w as a single chronologically ordered stream.
+// Each log set is read from its oldest backup to the active file, decompressing gzip backups transparently.
+// Backups compressed by an external command (see WithCompressionCommand) cannot be read and make Merge fail.
+// Lines are ordered by the time returned by timestamp (see RFC3339Timestamp). Lines with equal times keep
+// the order of paths. Lines written without a trailing newline are terminated with one.
+func Merge(w io.Writer, timestamp TimestampFunc, paths ...string) error {
+	h := make(mergeHeap, 0, len(paths))
+	defer func() {
+		for _, s := range h {
+			s.reader.close()
+		}
+	}()
+	for i, path := range paths {
+		files, err := logSetFiles(path)
+		if err != nil {
+			return err
+		}
+		s := &mergeSource{index: i, reader: &lineReader{fs: osFS{}, files: files}}
+		ok, err := s.advance(timestamp)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, s)
+		}
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		s := h[0]
+		if _, err := w.Write(s.line); err != nil {
+			return err
+		}
+		if s.line[len(s.line)-1] != '\n' {
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return err
+			}
+		}
+		ok, err := s.advance(timestamp)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package rollingfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMergeInterleavesLogSets verifies that lines from several log sets, including gzip backups
+// and continuation lines without timestamps, are merged in chronological order.
+func TestMergeInterleavesLogSets(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+	stamp := time.Now().Format(backupTimeLayout)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("2024-01-01T10:00:00Z a1\n  continued\n"))
+	zw.Close()
+	assert.NoError(t, os.WriteFile(a+"."+stamp+".0.gz", gz.Bytes(), 0644))
+	assert.NoError(t, os.WriteFile(a, []byte("2024-01-01T10:00:03Z a2\n"), 0644))
+	assert.NoError(t, os.WriteFile(b+"."+stamp+".0", []byte("2024-01-01T10:00:01Z b1\n"), 0644))
+	assert.NoError(t, os.WriteFile(b, []byte("2024-01-01T10:00:02Z b2\n2024-01-01T10:00:03Z b3"), 0644))
+
+	var out bytes.Buffer
+	err := Merge(&out, RFC3339Timestamp, a, b)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01-01T10:00:00Z a1\n"+
+		"  continued\n"+
+		"2024-01-01T10:00:01Z b1\n"+
+		"2024-01-01T10:00:02Z b2\n"+
+		"2024-01-01T10:00:03Z a2\n"+
+		"2024-01-01T10:00:03Z b3\n", out.String())
+}
+
+// TestMergeUsesMetadataStart verifies that lines without timestamps are ordered by the start time
+// recorded in the metadata sidecar of their backup.
+func TestMergeUsesMetadataStart(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+	stamp := time.Now().Format(backupTimeLayout)
+
+	write := func(path, content string, start time.Time) {
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		assert.NoError(t, (&RollingFile{mode: 0644, fs: osFS{}}).storeMetadata(path, BackupMetadata{Start: start}))
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	write(a+"."+stamp+".0", "a\n", base.Add(time.Minute))
+	write(b+"."+stamp+".0", "b\n", base)
+
+	var out bytes.Buffer
+	err := Merge(&out, RFC3339Timestamp, a, b)
+	assert.NoError(t, err)
+	assert.Equal(t, "b\na\n", out.String())
+}
+
+// TestMergeRejectsExternallyCompressedBackups verifies that backups compressed by an external command
+// are reported instead of being merged as text.
+func TestMergeRejectsExternallyCompressedBackups(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	stamp := time.Now().Format(backupTimeLayout)
+	assert.NoError(t, os.WriteFile(a+"."+stamp+".000000001.zst", []byte{0x28, 0xb5, 0x2f, 0xfd}, 0644))
+	assert.NoError(t, os.WriteFile(a, []byte("2024-01-01T10:00:00Z a\n"), 0644))
+
+	var out bytes.Buffer
+	err := Merge(&out, RFC3339Timestamp, a)
+	assert.ErrorContains(t, err, "compressed by an external command")
+	assert.Empty(t, out.String())
+
+	assert.False(t, isExternallyCompressed(a, a+"."+stamp+".000000001"))
+	assert.False(t, isExternallyCompressed(a, a+"."+stamp+".000000001.gz"))
+	assert.False(t, isExternallyCompressed(a, a+".bak"))
+	assert.True(t, isExternallyCompressed(a, a+".000000000001."+stamp+".xz"))
+}
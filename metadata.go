@@ -0,0 +1,147 @@
+package rollingfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const metadataSuffix = ".meta.json"
+
+// BackupMetadata describes a backup file. It is written as a JSON sidecar next to
+// each backup when enabled with WithMetadata.
+type BackupMetadata struct {
+	// File is the base name of the backup file, including the compression extension once compressed.
+	File string `json:"file"`
+	// Start and End are the times of the first and last write to the backup.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Lines, Bytes and SHA256 describe the uncompressed content.
+	Lines  int64  `json:"lines"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+	// Codec is the compression codec, or "none" for uncompressed backups.
+	Codec string `json:"codec"`
+	// CompressedBytes and CompressedSHA256 describe the compressed file.
+	CompressedBytes  int64  `json:"compressedBytes,omitempty"`
+	CompressedSHA256 string `json:"compressedSha256,omitempty"`
+}
+
+// metadataPath returns the path of the metadata sidecar of a backup, which does not change when the backup is compressed.
+func (l *RollingFile) metadataPath(backupPath string) string {
+	return strings.TrimSuffix(backupPath, l.compressionExt()) + metadataSuffix
+}
+
+// ReadMetadata reads the metadata sidecar of a backup file.
+func (l *RollingFile) ReadMetadata(backupPath string) (BackupMetadata, error) {
+	var meta BackupMetadata
+	data, err := l.readFile(l.metadataPath(backupPath))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+// readMetadataFor reads the metadata sidecar of a backup outside of a RollingFile,
+// assuming the default gzip compression.
+func readMetadataFor(backupPath string) (BackupMetadata, error) {
+	return (&RollingFile{fs: osFS{}}).ReadMetadata(backupPath)
+}
+
+// writeMetadata computes and writes the metadata sidecar of an uncompressed backup.
+// Backups already removed by cleanup are skipped. It holds the cleanup mutex, so that
+// cleanup never removes a backup between computing and writing its sidecar.
+func (l *RollingFile) writeMetadata(backupPath string, start, end time.Time) error {
+	l.cleanupMutex.Lock()
+	defer l.cleanupMutex.Unlock()
+	size, sum, lines, err := l.digestFile(backupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return l.storeMetadata(backupPath, BackupMetadata{
+		File:   filepath.Base(backupPath),
+		Start:  start,
+		End:    end,
+		Lines:  lines,
+		Bytes:  size,
+		SHA256: sum,
+		Codec:  "none",
+	})
+}
+
+// updateCompressedMetadata records the compressed file in the metadata sidecar of a backup.
+// Backups without a sidecar, e.g. rotated before metadata was enabled, are skipped.
+func (l *RollingFile) updateCompressedMetadata(backupPath, compressedPath string) error {
+	meta, err := l.ReadMetadata(backupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	size, sum, _, err := l.digestFile(compressedPath)
+	if err != nil {
+		return err
+	}
+	meta.File = filepath.Base(compressedPath)
+	meta.Codec = l.compressionCodec()
+	meta.CompressedBytes = size
+	meta.CompressedSHA256 = sum
+	return l.storeMetadata(backupPath, meta)
+}
+
+// storeMetadata atomically replaces the metadata sidecar of a backup.
+func (l *RollingFile) storeMetadata(backupPath string, meta BackupMetadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := l.metadataPath(backupPath)
+	tmpPath := path + tmpSuffix
+	if err := l.createFile(tmpPath, append(data, '\n'), l.mode); err != nil {
+		return err
+	}
+	return l.fs.Rename(tmpPath, path)
+}
+
+// digestFile returns the size, hex-encoded SHA-256 checksum and number of lines of a file.
+func (l *RollingFile) digestFile(path string) (size int64, sum string, lines int64, err error) {
+	file, err := l.open(path)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	defer file.Close()
+	return digest(file)
+}
+
+// digest returns the size, hex-encoded SHA-256 checksum and number of lines read from r.
+func digest(r io.Reader) (size int64, sum string, lines int64, err error) {
+	hash := sha256.New()
+	buf := make([]byte, 32*1024)
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			lines += int64(bytes.Count(buf[:n], []byte("\n")))
+			size += int64(n)
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return 0, "", 0, rerr
+		}
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), lines, nil
+}
@@ -0,0 +1,71 @@
+package rollingfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMetadataSidecars verifies that a sidecar describing the content is written for each backup
+// and updated once the backup is compressed.
+func TestMetadataSidecars(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "meta.log")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMetadata(),
+		WithCompression(),
+	)
+	assert.NoError(t, err)
+
+	content := "one\ntwo\nthree\n" + strings.Repeat("m", 59) + "\n"
+	_, err = logger.Write([]byte(content))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte(strings.Repeat("n", 59) + "\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	backups, err := logger.listBackups(logPath)
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	meta, err := logger.ReadMetadata(backups[0])
+	assert.NoError(t, err)
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, filepath.Base(backups[0]), meta.File)
+	assert.Equal(t, int64(4), meta.Lines)
+	assert.Equal(t, int64(len(content)), meta.Bytes)
+	assert.Equal(t, hex.EncodeToString(sum[:]), meta.SHA256)
+	assert.Equal(t, "gzip", meta.Codec)
+	assert.False(t, meta.Start.IsZero())
+	assert.False(t, meta.End.Before(meta.Start))
+
+	info, err := os.Stat(backups[0])
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), meta.CompressedBytes)
+}
+
+// TestMetadataRemovedWithBackup ensures that sidecars are deleted together with their backups.
+func TestMetadataRemovedWithBackup(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "meta.log")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMaxBackups(1),
+		WithMetadata(),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err := logger.Write([]byte(strings.Repeat("m", 80) + "\n"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	sidecars, err := filepath.Glob(logPath + ".*" + metadataSuffix)
+	assert.NoError(t, err)
+	assert.Len(t, sidecars, 1)
+}
@@ -0,0 +1,171 @@
+package rollingfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMmapChunk is the number of bytes the mapping is grown by when WithMmap is given no chunk size.
+const defaultMmapChunk = 4 << 20
+
+// errMmapUnsupported is returned by the mmap primitives on platforms without mmap.
+var errMmapUnsupported = errors.New("mmap not supported")
+
+// mapFile sets up the mapping of the active file for writing, if enabled. If the file cannot be mapped,
+// mmap is disabled and writes fall back to the normal path.
+func (l *RollingFile) mapFile() {
+	if l.mmapChunk <= 0 || l.mapping != nil {
+		return
+	}
+	if err := l.growMapping(0); err != nil {
+		l.disableMmap(err)
+	}
+}
+
+// disableMmap falls back to the normal write path for the rest of the RollingFile's lifetime.
+func (l *RollingFile) disableMmap(cause error) {
+	l.unmapFile()
+	l.mmapChunk = 0
+	if !errors.Is(cause, errMmapUnsupported) {
+		l.errorHandler(fmt.Errorf("falling back to regular writes, mmap failed: %w", cause))
+	}
+}
+
+// preallocPath returns the path of the sidecar file marking the active file at path as preallocated.
+// It records the written size when the file was extended, so that a crash before the file is truncated
+// back can be told apart from data ending in zero bytes.
+func preallocPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".prealloc")
+}
+
+// growMapping replaces the mapping with one covering at least n bytes beyond the current size,
+// preallocating the file accordingly.
+func (l *RollingFile) growMapping(n int) error {
+	file, ok := l.file.(*os.File)
+	if !ok {
+		return errMmapUnsupported
+	}
+	if err := l.unmapFile(); err != nil {
+		return err
+	}
+	if err := l.createFile(preallocPath(l.file.Name()), []byte(strconv.FormatInt(l.size, 10)+"\n"), l.mode); err != nil {
+		return err
+	}
+	// Mappings must start at a page boundary.
+	base := l.size - l.size%int64(os.Getpagesize())
+	end := l.size + max(l.mmapChunk, int64(n))
+	if err := preallocate(file, end); err != nil {
+		l.fs.Remove(preallocPath(l.file.Name()))
+		return err
+	}
+	mapping, err := mmapFile(file, base, int(end-base))
+	if err != nil {
+		if l.file.Truncate(l.size) == nil {
+			l.fs.Remove(preallocPath(l.file.Name()))
+		}
+		return err
+	}
+	l.mapping, l.mappingBase = mapping, base
+	return nil
+}
+
+// unmapFile removes the mapping, if any, and truncates the file to the written size.
+func (l *RollingFile) unmapFile() error {
+	if l.mapping == nil {
+		return nil
+	}
+	err := munmapFile(l.mapping)
+	l.mapping = nil
+	if terr := l.file.Truncate(l.size); terr != nil {
+		if err == nil {
+			err = terr
+		}
+		return err
+	}
+	if rerr := l.fs.Remove(preallocPath(l.file.Name())); err == nil && !errors.Is(rerr, os.ErrNotExist) {
+		err = rerr
+	}
+	return err
+}
+
+// trimPreallocated truncates preallocated space that was never written because the previous
+// process did not close the file. Only files marked by the preallocation sidecar are trimmed, and
+// only trailing zero bytes beyond the size recorded in it, as earlier data was written before.
+func (l *RollingFile) trimPreallocated() error {
+	written, ok, err := l.readPreallocMarker(l.file.Name())
+	if err != nil || !ok {
+		return err
+	}
+	size, err := writtenSize(l.readActive, l.size, written)
+	if err != nil {
+		return err
+	}
+	if size != l.size {
+		if err := l.file.Truncate(size); err != nil {
+			return err
+		}
+		l.size = size
+	}
+	return l.fs.Remove(preallocPath(l.file.Name()))
+}
+
+// readPreallocMarker returns the written size recorded in the preallocation sidecar of the file at path,
+// and false if the file is not preallocated.
+func (l *RollingFile) readPreallocMarker(path string) (int64, bool, error) {
+	marker := preallocPath(path)
+	data, err := l.readFile(marker)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	written, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid preallocation marker %q: %w", marker, err)
+	}
+	return written, true, nil
+}
+
+// writtenSize returns size without the trailing zero bytes beyond written, the size recorded in the
+// preallocation sidecar, reading the file with readAt.
+func writtenSize(readAt func(buf []byte, off int64) error, size, written int64) (int64, error) {
+	const block = 64 << 10
+	buf := make([]byte, block)
+	for size > written {
+		n := min(size-written, block)
+		if err := readAt(buf[:n], size-n); err != nil {
+			return 0, err
+		}
+		trimmed := bytes.TrimRight(buf[:n], "\x00")
+		size -= n - int64(len(trimmed))
+		if len(trimmed) > 0 {
+			break
+		}
+	}
+	return size, nil
+}
+
+// writeMapped copies data into the mapping, growing it as needed.
+func (l *RollingFile) writeMapped(data []byte) (int, error) {
+	if l.size+int64(len(data)) > l.mappingBase+int64(len(l.mapping)) {
+		if err := l.growMapping(len(data)); err != nil {
+			l.disableMmap(err)
+			return l.file.Write(data)
+		}
+	}
+	copy(l.mapping[l.size-l.mappingBase:], data)
+	if l.mmapSyncInterval > 0 && time.Since(l.lastMsync) >= l.mmapSyncInterval {
+		l.lastMsync = time.Now()
+		if err := msyncFile(l.mapping, false); err != nil {
+			l.errorHandler(fmt.Errorf("failed to msync log file: %w", err))
+		}
+	}
+	return len(data), nil
+}
@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd)
+
+package rollingfile
+
+import "os"
+
+func mmapFile(*os.File, int64, int) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmapFile([]byte) error {
+	return errMmapUnsupported
+}
+
+func msyncFile([]byte, bool) error {
+	return errMmapUnsupported
+}
@@ -0,0 +1,112 @@
+//go:build linux || darwin || freebsd
+
+package rollingfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMmapWritesAndRotates verifies that writes through the mapping end up in the file and its backups
+// at their exact size, across rotations and mapping growth.
+func TestMmapWritesAndRotates(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "mmap.log")
+	logger, err := New(logPath, WithMmap(64, time.Nanosecond), WithMaxBytes(100))
+	assert.NoError(t, err)
+
+	line := strings.Repeat("m", 29) + "\n"
+	for i := 0; i < 5; i++ {
+		_, err := logger.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	info, err := os.Stat(logPath)
+	assert.NoError(t, err)
+	assert.Greater(t, info.Size(), int64(2*len(line)), "the active file should be preallocated")
+
+	r, err := logger.ReadCurrent()
+	assert.NoError(t, err)
+	current, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	r.Close()
+	assert.Equal(t, strings.Repeat(line, 2), string(current))
+
+	assert.NoError(t, logger.Sync())
+	assert.NoError(t, logger.Close())
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat(line, 2), string(contents))
+	backups, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		contents, err = os.ReadFile(backups[0])
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Repeat(line, 3), string(contents))
+	}
+}
+
+// TestMmapTrimsPreallocatedSpace verifies that unwritten preallocated space left behind by a crash
+// is removed before writing continues.
+func TestMmapTrimsPreallocatedSpace(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "crash.log")
+	assert.NoError(t, os.WriteFile(logPath, append([]byte("before crash\n"), make([]byte, 1000)...), 0644))
+	assert.NoError(t, os.WriteFile(preallocPath(logPath), []byte("5\n"), 0644))
+
+	logger, err := New(logPath, WithMmap(0, 0))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("after restart\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "before crash\nafter restart\n", string(contents))
+	assert.NoFileExists(t, preallocPath(logPath))
+}
+
+// TestMmapKeepsWrittenZeros ensures that trailing zero bytes are only trimmed beyond the size
+// recorded when the file was preallocated, and not at all from files that were not.
+func TestMmapKeepsWrittenZeros(t *testing.T) {
+	dir := t.TempDir()
+	data := append([]byte("data"), make([]byte, 8)...)
+
+	plainPath := filepath.Join(dir, "plain.log")
+	assert.NoError(t, os.WriteFile(plainPath, data, 0644))
+	markedPath := filepath.Join(dir, "marked.log")
+	assert.NoError(t, os.WriteFile(markedPath, append(data, make([]byte, 100)...), 0644))
+	assert.NoError(t, os.WriteFile(preallocPath(markedPath), []byte("12\n"), 0644))
+
+	for _, path := range []string{plainPath, markedPath} {
+		logger, err := New(path, WithMmap(0, 0), WithLengthPrefixedFrames())
+		assert.NoError(t, err)
+		assert.NoError(t, logger.Close())
+		contents, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, data, contents, path)
+	}
+}
+
+// TestMmapSearchStopsAtWrittenSize verifies that searching a mapped active file does not return the
+// zero bytes of its preallocated space.
+func TestMmapSearchStopsAtWrittenSize(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "search.log")
+	logger, err := New(logPath, WithMmap(0, 0))
+	assert.NoError(t, err)
+	defer logger.Close()
+	_, err = logger.Write([]byte("first\nsecond\n"))
+	assert.NoError(t, err)
+
+	var lines []string
+	err = Search(logPath, SearchQuery{}, func(m SearchMatch) error {
+		lines = append(lines, string(m.Line))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first\n", "second\n"}, lines)
+}
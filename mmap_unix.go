@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+
+package rollingfile
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func mmapFile(file *os.File, offset int64, length int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), offset, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+func munmapFile(b []byte) error {
+	return syscall.Munmap(b)
+}
+
+// msyncFile flushes the mapping b, waiting for the write-back to complete if wait is set.
+func msyncFile(b []byte, wait bool) error {
+	flags := syscall.MS_ASYNC
+	if wait {
+		flags = syscall.MS_SYNC
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)), uintptr(flags))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package rollingfile
+
+// LimitKind identifies the limit reported by a NearLimit notification.
+type LimitKind int
+
+const (
+	// LimitMaxSize is the maximum size of the active file (see WithMaxBytes).
+	LimitMaxSize LimitKind = iota + 1
+	// LimitMaxTotalBytes is the total size budget of the active file and backups (see WithMaxTotalBytes).
+	LimitMaxTotalBytes
+)
+
+// NearLimit describes a limit that has been crossed by the configured percentage.
+type NearLimit struct {
+	Kind  LimitKind
+	Used  int64
+	Limit int64
+}
+
+// checkNearLimit queues a notification for the near-limit handler once for every
+// crossing of the configured percentage. The active file limit is re-armed by rotation,
+// the total budget once usage drops below the threshold again. The notifications are
+// delivered by unlock.
+func (l *RollingFile) checkNearLimit() {
+	if l.maxSize > 0 && !l.nearMaxSizeNotified && l.size*100 >= l.maxSize*int64(l.nearLimitPercent) {
+		l.nearMaxSizeNotified = true
+		l.queuedNearLimits = append(l.queuedNearLimits, NearLimit{Kind: LimitMaxSize, Used: l.size, Limit: l.maxSize})
+	}
+	if l.maxTotalBytes > 0 {
+		used := l.size + l.backupBytes.Load()
+		near := used*100 >= l.maxTotalBytes*int64(l.nearLimitPercent)
+		if near && !l.nearTotalNotified {
+			l.queuedNearLimits = append(l.queuedNearLimits, NearLimit{Kind: LimitMaxTotalBytes, Used: used, Limit: l.maxTotalBytes})
+		}
+		l.nearTotalNotified = near
+	}
+}
@@ -0,0 +1,85 @@
+package rollingfile
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNearLimitNotifications verifies that each limit is reported once per crossing.
+func TestNearLimitNotifications(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "near.log")
+	var events []NearLimit
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMaxTotalBytes(1000),
+		WithNearLimitFunc(80, func(e NearLimit) { events = append(events, e) }),
+	)
+	assert.NoError(t, err)
+
+	line := []byte(strings.Repeat("n", 39) + "\n")
+	_, err = logger.Write(line)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = logger.Write(line)
+	assert.NoError(t, err)
+	assert.Equal(t, []NearLimit{{Kind: LimitMaxSize, Used: 80, Limit: 100}}, events)
+
+	// Further writes to the same file do not notify again.
+	_, err = logger.Write([]byte("\n"))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// Rotation re-arms the notification for the new file.
+	_, err = logger.Write(line)
+	assert.NoError(t, err)
+	_, err = logger.Write(line)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	err = logger.Close()
+	assert.NoError(t, err)
+}
+
+// TestNearLimitTotalBytes verifies that the total budget is reported when nearly exhausted.
+func TestNearLimitTotalBytes(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "near.log")
+	var events []NearLimit
+	logger, err := New(logPath,
+		WithMaxTotalBytes(100),
+		WithNearLimitFunc(50, func(e NearLimit) { events = append(events, e) }),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := logger.Write([]byte(strings.Repeat("t", 29) + "\n"))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []NearLimit{{Kind: LimitMaxTotalBytes, Used: 60, Limit: 100}}, events)
+
+	err = logger.Close()
+	assert.NoError(t, err)
+}
+
+// TestNearLimitFuncCallsRollingFile verifies that the near-limit function may call methods of the RollingFile.
+func TestNearLimitFuncCallsRollingFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "reentrant.log")
+	var logger *RollingFile
+	var names []string
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithNearLimitFunc(50, func(NearLimit) {
+			names = append(names, logger.Name())
+			assert.NoError(t, logger.Healthy())
+		}),
+	)
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte(strings.Repeat("n", 59) + "\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, []string{logPath}, names)
+}
@@ -1,8 +1,12 @@
 package rollingfile
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -14,13 +18,10 @@ type Option func(*RollingFile)
 // If the file does not exist, it is created with default permissions (0644).
 
 func New(path string, options ...Option) (logger *RollingFile, err error) {
-	mode := os.FileMode(0644)
-	info, err := os.Stat(path)
-	if err == nil {
-		mode = info.Mode()
-	}
 	logger = &RollingFile{
-		mode: mode,
+		fs:                    osFS{},
+		openFlags:             os.O_RDWR,
+		fallbackRetryInterval: defaultFallbackRetryInterval,
 		errorHandler: func(err error) {
 			fmt.Fprintf(os.Stderr, "RollingFile error: %v\n", err)
 		},
@@ -29,17 +30,87 @@ func New(path string, options ...Option) (logger *RollingFile, err error) {
 	for _, o := range options {
 		o(logger)
 	}
-	logger.file, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, logger.mode)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %v", err)
+	if logger.mode == 0 {
+		logger.mode = 0644
+		if info, err := logger.fs.Stat(path); err == nil {
+			logger.mode = info.Mode()
+		}
 	}
-
-	stat, err := logger.file.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat log file: %v", err)
+	if logger.mmapChunk > 0 && logger.directBuffer > 0 {
+		logger.optionErr = errors.New("WithMmap and WithDirectIO are mutually exclusive")
+	}
+	if logger.hardQuota && logger.maxTotalBytes <= 0 {
+		logger.optionErr = errors.New("WithHardQuota requires a positive WithMaxTotalBytes budget")
+	}
+	if logger.framed && (logger.lineEnding != 0 || logger.linePrefix != nil || logger.jsonLines || logger.truncateOversized ||
+		logger.recoveryMarker || logger.header != nil || logger.fallbackPath != "") {
+		logger.optionErr = errors.New("WithLengthPrefixedFrames cannot be combined with line-oriented options")
+	}
+	if logger.jsonLines && logger.linePrefix != nil {
+		logger.optionErr = errors.New("WithJSONLines cannot be combined with WithLinePrefix")
+	}
+	if !logger.onOSFS() {
+		switch {
+		case logger.exclusive:
+			logger.optionErr = fmt.Errorf("WithExclusive %w", errOSFSRequired)
+		case logger.immutableBackups:
+			logger.optionErr = fmt.Errorf("immutable backups %w", errOSFSRequired)
+		case logger.postRotateCommand != nil:
+			logger.optionErr = fmt.Errorf("WithPostRotateCommand %w", errOSFSRequired)
+		}
+	}
+	if logger.optionErr != nil {
+		return nil, fmt.Errorf("invalid option: %w", logger.optionErr)
+	}
+	logger.recordErrors()
+	logger.path = path
+	if logger.trigger && logger.triggerPath == "" {
+		logger.triggerPath = defaultTriggerPath(path)
+	}
+	if logger.preflight {
+		if err = logger.runPreflight(path); err != nil {
+			return nil, fmt.Errorf("preflight check failed: %w", err)
+		}
+	}
+	if logger.backupDir != "" {
+		if !filepath.IsAbs(logger.backupDir) {
+			logger.backupDir = filepath.Join(filepath.Dir(path), logger.backupDir)
+		}
+		if err = logger.fs.MkdirAll(logger.backupDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create backup directory: %w", err)
+		}
+	}
+	if logger.exclusive {
+		if err = logger.acquireLock(); err != nil {
+			return nil, fmt.Errorf("failed to lock log file: %w", err)
+		}
+	}
+	if logger.persistentSequence {
+		if err = logger.loadSequence(); err != nil {
+			logger.releaseLock()
+			return nil, fmt.Errorf("failed to load rotation sequence: %w", err)
+		}
+	}
+	if !logger.lazyOpen {
+		if err = logger.openActive(); err != nil {
+			logger.releaseLock()
+			return nil, err
+		}
+	}
+	if err = logger.recoverState(); err != nil {
+		if logger.file != nil {
+			logger.closeFile()
+		}
+		logger.releaseLock()
+		return nil, fmt.Errorf("failed to recover state from backup files: %v", err)
+	}
+	logger.scheduleRotation(logger.now())
+	if logger.compress {
+		logger.startCompression()
+	}
+	if logger.diskBudget != nil {
+		logger.diskBudget.add(logger)
 	}
-	logger.size = stat.Size()
-
 	return logger, nil
 }
 
@@ -50,6 +121,63 @@ func WithMaxBytes(maxBytes int64) Option {
 	}
 }
 
+// WithMaxSize returns an option to set the maximum size before rotation from a human-readable
+// string such as "250MB" or "1GiB" (see ParseSize). New fails if the size cannot be parsed.
+func WithMaxSize(size string) Option {
+	return func(w *RollingFile) {
+		maxBytes, err := ParseSize(size)
+		if err != nil {
+			w.optionErr = err
+			return
+		}
+		w.maxSize = maxBytes
+	}
+}
+
+// WithRotationInterval returns an option to additionally rotate the file every interval.
+// Rotations are aligned to multiples of interval in local time (e.g. 24h rotates at midnight)
+// and performed by the first write after the boundary. Empty files are not rotated.
+func WithRotationInterval(interval time.Duration) Option {
+	return func(w *RollingFile) {
+		w.rotationInterval = interval
+	}
+}
+
+// WithTriggerFile returns an option to rotate when the trigger file at path appears, e.g. created by a cron job
+// or an ops script, and to remove it afterwards. The trigger file defaults to the hidden ".<name>.rotate" next to
+// the log file if path is empty. A path that looks like a backup of the log file is never treated as a trigger.
+// Like time-based rotation, the rotation is performed by the next write. The trigger file is checked at most once
+// per interval, or on every write if interval is zero.
+func WithTriggerFile(path string, interval time.Duration) Option {
+	return func(w *RollingFile) {
+		w.trigger = true
+		w.triggerPath = path
+		w.triggerInterval = interval
+	}
+}
+
+// WithRotationJitter returns an option to delay each time-based rotation by a random duration in [0, jitter),
+// so that fleets of instances do not rotate, compress and upload at the same moment.
+// The jitter should be smaller than the rotation interval.
+func WithRotationJitter(jitter time.Duration) Option {
+	return func(w *RollingFile) {
+		w.rotationJitter = jitter
+	}
+}
+
+// WithHealthWindow returns an option to make Healthy report an error while any of the last n writes or
+// any of the last n rotations failed, instead of only the last one. New fails if n is not positive.
+func WithHealthWindow(n int) Option {
+	return func(w *RollingFile) {
+		if n <= 0 {
+			w.optionErr = fmt.Errorf("health window must be positive, got %d", n)
+			return
+		}
+		w.writeHealth = healthWindow{errs: make([]error, n)}
+		w.rotationHealth = healthWindow{errs: make([]error, n)}
+	}
+}
+
 // WithErrorHandler returns an option to set a custom handler for errors occurring during the cleanup of backup files.
 func WithErrorHandler(handler func(error)) Option {
 	return func(w *RollingFile) {
@@ -64,6 +192,31 @@ func WithMaxBackups(maxBackups int) Option {
 	}
 }
 
+// WithMaxUncompressedBackups returns an option to limit the number of uncompressed backup files,
+// independently of WithMaxBackups. With compression enabled, the newest backups stay uncompressed
+// and older ones are compressed once they exceed the limit; without compression they are deleted.
+func WithMaxUncompressedBackups(n int) Option {
+	return func(w *RollingFile) {
+		w.maxUncompressedBackups = n
+	}
+}
+
+// WithMaxCompressedBackups returns an option to limit the number of compressed backup files,
+// independently of WithMaxBackups.
+func WithMaxCompressedBackups(n int) Option {
+	return func(w *RollingFile) {
+		w.maxCompressedBackups = n
+	}
+}
+
+// WithMinBackups returns an option to set the minimum number of backup files retained regardless of their age.
+// It only limits age-based expiry (WithMaxAge, WithMaxDays); WithMaxBackups still applies.
+func WithMinBackups(minBackups int) Option {
+	return func(w *RollingFile) {
+		w.minBackups = minBackups
+	}
+}
+
 // WithMaxAge returns an option to set the maximum age of backup files before deletion.
 func WithMaxAge(age time.Duration) Option {
 	return func(w *RollingFile) {
@@ -71,9 +224,444 @@ func WithMaxAge(age time.Duration) Option {
 	}
 }
 
+// WithMaxDays returns an option to keep only backups from the last n calendar days, including today.
+// Days begin at local midnight, so a backup from yesterday 23:59 expires at the start of day n,
+// regardless of DST changes in between.
+func WithMaxDays(n int) Option {
+	return func(w *RollingFile) {
+		w.maxDays = n
+	}
+}
+
+// WithMaxTotalBytes returns an option to limit the total size of the active file and all backups.
+// Cleanup deletes the oldest backups until the backups fit into the budget while leaving room for the
+// active file to grow to its maximum size.
+func WithMaxTotalBytes(maxTotalBytes int64) Option {
+	return func(w *RollingFile) {
+		w.maxTotalBytes = maxTotalBytes
+	}
+}
+
+// WithDiskBudget returns an option to share budget with other RollingFiles, e.g. all logs in a directory.
+// When the total size of their active files and backups exceeds the budget, the oldest backups across all
+// of them are deleted first, keeping the minimum number of backups of each (see WithMinBackups).
+func WithDiskBudget(budget *DiskBudget) Option {
+	return func(w *RollingFile) {
+		w.diskBudget = budget
+	}
+}
+
+// WithHardQuota returns an option to never exceed the budget set by WithMaxTotalBytes.
+// If a write does not fit even after deleting all deletable backups, Write fails with ErrQuotaExceeded.
+// New fails if no budget is set.
+func WithHardQuota() Option {
+	return func(w *RollingFile) {
+		w.hardQuota = true
+	}
+}
+
+// WithNearLimitFunc returns an option to call fn when the active file reaches percent of the maximum size,
+// or the active file and backups reach percent of the budget set by WithMaxTotalBytes.
+// fn is called from Write once per crossing and should return quickly. It is called after the
+// RollingFile has been unlocked, so it may call its methods.
+func WithNearLimitFunc(percent int, fn func(NearLimit)) Option {
+	return func(w *RollingFile) {
+		w.nearLimitPercent = percent
+		w.nearLimitFunc = fn
+	}
+}
+
+// WithEventFunc returns an option to receive typed events about rotation, cleanup and compression
+// (see Event). fn is called from the writing goroutine as well as from background cleanup and
+// compression goroutines, so it must be safe for concurrent use and should return quickly. It is never
+// called with the RollingFile locked, so it may call its methods.
+func WithEventFunc(fn func(Event)) Option {
+	return func(w *RollingFile) {
+		w.eventFunc = fn
+	}
+}
+
+// WithPreflight returns an option to verify at New that the log directory is writable, that files can be
+// created and renamed in it, and that it has enough free space for the configured size limits,
+// instead of discovering problems at the first rotation.
+func WithPreflight() Option {
+	return func(w *RollingFile) {
+		w.preflight = true
+	}
+}
+
+// WithPersistentSequence returns an option to name backups by a monotonically increasing rotation sequence
+// ("<name>.<sequence>.<timestamp>") persisted in a hidden sidecar file next to the log file, so that
+// ordering backups by name stays unambiguous across restarts and clock changes (NTP steps, DST).
+func WithPersistentSequence() Option {
+	return func(w *RollingFile) {
+		w.persistentSequence = true
+	}
+}
+
 // WithMode returns an option to set the file mode for the log file on creation.
 func WithMode(mode os.FileMode) Option {
 	return func(w *RollingFile) {
 		w.mode = mode
 	}
 }
+
+// WithCompression returns an option to gzip backup files in the background after rotation.
+func WithCompression() Option {
+	return func(w *RollingFile) {
+		w.compress = true
+	}
+}
+
+// WithCompressionWorkers returns an option to limit the number of concurrent compression workers
+// and the number of backups that may wait for compression. Backups rotated while the queue is full
+// are reported to the error handler and enqueued again by the cleanup after the next rotation,
+// or by Close, which waits for the queue to have room.
+func WithCompressionWorkers(workers, queueSize int) Option {
+	return func(w *RollingFile) {
+		w.compressionWorkers = workers
+		w.compressionQueueSize = queueSize
+	}
+}
+
+// WithCompressionCommand returns an option to compress backup files by invoking an external command
+// instead of the built-in gzip compression. The command reads the backup on stdin and writes the
+// compressed data to stdout; ext is appended to the backup name and must start with a dot (e.g. ".zst").
+// Non-zero exit statuses are reported to the error handler together with the command's stderr.
+func WithCompressionCommand(ext string, name string, args ...string) Option {
+	return func(w *RollingFile) {
+		if len(ext) < 2 || ext[0] != '.' {
+			w.optionErr = fmt.Errorf("compression extension %q must start with a dot", ext)
+			return
+		}
+		w.compress = true
+		w.compressExt = ext
+		w.compressCommand = append([]string{name}, args...)
+	}
+}
+
+// WithPostRotateCommand returns an option to run an external command after each rotation,
+// similar to logrotate's postrotate scripts. The backup path is appended as the last argument.
+// The command runs in the background and is killed if it does not finish within timeout (0 disables the timeout).
+// Backups are only compressed after the command has finished. Failures are reported to the error handler.
+func WithPostRotateCommand(timeout time.Duration, name string, args ...string) Option {
+	return func(w *RollingFile) {
+		w.postRotateTimeout = timeout
+		w.postRotateCommand = append([]string{name}, args...)
+	}
+}
+
+// WithLineEnding returns an option to normalize line endings of written data to LF or CRLF.
+func WithLineEnding(ending LineEnding) Option {
+	return func(w *RollingFile) {
+		w.lineEnding = ending
+	}
+}
+
+// WithLinePrefix returns an option to prepend the result of prefix (commonly a timestamp) to every line
+// passing through Write, including each line of a write containing multiple newlines.
+// prefix is called once per Write and must not return data containing a newline.
+func WithLinePrefix(prefix func() []byte) Option {
+	return func(w *RollingFile) {
+		w.linePrefix = prefix
+	}
+}
+
+// WithJSONLines returns an option to verify that every Write is a single JSON object terminated by a newline.
+// If quarantinePath is empty, invalid writes are rejected with ErrInvalidJSONLine. Otherwise they are
+// appended to the quarantine file instead and Write reports success. It cannot be combined with WithLinePrefix.
+func WithJSONLines(quarantinePath string) Option {
+	return func(w *RollingFile) {
+		w.jsonLines = true
+		w.quarantinePath = quarantinePath
+	}
+}
+
+// WithLengthPrefixedFrames returns an option to write binary records, such as protobuf messages, instead of text.
+// Every Write is one record, prefixed with its length as an unsigned varint, and the file is only rotated between
+// records. Records can be read with FrameReader or ReadFrames. It cannot be combined with options that add or
+// rewrite lines, such as WithLineEnding, WithLinePrefix, WithJSONLines, WithTruncateOversized, WithRecoveryMarker,
+// WithHeader or WithFallbackPath.
+func WithLengthPrefixedFrames() Option {
+	return func(w *RollingFile) {
+		w.framed = true
+	}
+}
+
+// WithStrictMode returns an option to set newly created files, the active file and copied or compressed backups,
+// to exactly the configured mode (see WithMode), which a restrictive umask would otherwise narrow.
+func WithStrictMode() Option {
+	return func(w *RollingFile) {
+		w.strictMode = true
+	}
+}
+
+// WithBackupMode returns an option to set the permissions of backups right after rotation, e.g. 0600 for backups
+// while the active file is 0644 for a tailer. Compressed backups keep the mode. By default, backups keep the mode
+// of the active file.
+func WithBackupMode(mode os.FileMode) Option {
+	return func(w *RollingFile) {
+		w.backupMode = mode.Perm()
+	}
+}
+
+// WithReadOnlyBackups returns an option to make backups read-only (0440, or the mode set with WithBackupMode
+// without write permissions) right after rotation and compression, so historical logs can't be modified by the
+// application account. With immutable, the immutable attribute is set as well where supported (Linux, requires
+// CAP_LINUX_IMMUTABLE); it is cleared again when compression or retention removes the backup.
+func WithReadOnlyBackups(immutable bool) Option {
+	return func(w *RollingFile) {
+		w.readOnlyBackups = true
+		w.immutableBackups = immutable
+	}
+}
+
+// WithHeader returns an option to write the result of header at the start of every file, right before
+// the first write to it, e.g. a schema record for JSON Lines files. The header should end with a newline.
+// It counts towards the maximum size and the budget set by WithMaxTotalBytes.
+func WithHeader(header func() []byte) Option {
+	return func(w *RollingFile) {
+		w.header = header
+	}
+}
+
+// WithJSONHeader returns an option to write v, marshalled as a JSON line, at the start of every file (see WithHeader).
+func WithJSONHeader(v any) Option {
+	return func(w *RollingFile) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			w.optionErr = fmt.Errorf("invalid JSON header: %w", err)
+			return
+		}
+		data = append(data, '\n')
+		w.header = func() []byte { return data }
+	}
+}
+
+// WithTruncateOversized returns an option to truncate writes exceeding the maximum size instead of rejecting them.
+// The removed tail is replaced with a "...[truncated N bytes]" marker, so the head of the line is preserved.
+func WithTruncateOversized() Option {
+	return func(w *RollingFile) {
+		w.truncateOversized = true
+	}
+}
+
+// WithTee returns an option to copy every write, after all transformations, to w as well.
+// The option may be given multiple times. Errors writing to w are passed to the error handler
+// and do not fail the write to the file.
+func WithTee(w io.Writer) Option {
+	return func(rf *RollingFile) {
+		rf.tees = append(rf.tees, w)
+	}
+}
+
+// WithExclusive returns an option to take an advisory lock on the path (via a hidden ".<name>.lock" file
+// next to it) for the lifetime of the RollingFile. New fails fast with ErrLocked if another instance,
+// in this or another process, already holds the lock. It is only supported on Unix-like systems.
+func WithExclusive() Option {
+	return func(w *RollingFile) {
+		w.exclusive = true
+	}
+}
+
+// WithRecoveryMarker returns an option to check on New whether the existing file ends mid-line, which is
+// likely after a crash, and if so to terminate the line and append RecoveryMarker before writing,
+// so that parsers don't glue two records together.
+func WithRecoveryMarker() Option {
+	return func(w *RollingFile) {
+		w.recoveryMarker = true
+	}
+}
+
+// WithMmap returns an option to write through a shared memory mapping of the active file instead of write
+// calls, reducing syscall overhead at very high write rates. The file is preallocated and the mapping grown by
+// chunkSize bytes (4 MiB if zero) at a time, and truncated to the written size on rotation and Close. With a
+// positive syncInterval, dirty pages are scheduled for write-back (msync) at most once per interval; Sync
+// always flushes them. While the file is preallocated, a hidden ".<name>.prealloc" sidecar file records the
+// written size, so that space left behind by a crash can be trimmed on the next open. Where mapping the file is
+// not supported or its space cannot be preallocated, which requires fallocate on Linux, writes fall back to the
+// normal path.
+func WithMmap(chunkSize int64, syncInterval time.Duration) Option {
+	return func(w *RollingFile) {
+		if chunkSize <= 0 {
+			chunkSize = defaultMmapChunk
+		}
+		w.mmapChunk = chunkSize
+		w.mmapSyncInterval = syncInterval
+	}
+}
+
+// WithDirectIO returns an option to write with direct I/O (O_DIRECT), bypassing the page cache, for appliances
+// that must not pollute it with log data. Writes are collected in an aligned buffer of bufferSize bytes (1 MiB if
+// zero), rounded up to 4 KiB blocks, which is written whenever it is full and on Sync, rotation and Close. Buffered
+// data is therefore only visible to readers of the file after those. Where the platform or filesystem rejects
+// direct I/O, writes fall back to the normal path. It is only supported on Linux.
+func WithDirectIO(bufferSize int) Option {
+	return func(w *RollingFile) {
+		if bufferSize <= 0 {
+			bufferSize = defaultDirectBuffer
+		}
+		w.directBuffer = bufferSize
+	}
+}
+
+// WithLazyOpen returns an option to defer creating and opening the file from New to the first write,
+// so that loggers which are never written to leave no empty files behind.
+func WithLazyOpen() Option {
+	return func(w *RollingFile) {
+		w.lazyOpen = true
+	}
+}
+
+// WithOpenFlags returns an option to set the flags the log file is opened with, in New and after every
+// rotation, instead of os.O_RDWR: e.g. os.O_WRONLY, os.O_EXCL to refuse continuing an existing file,
+// syscall.O_NOFOLLOW to refuse symlinks or os.O_SYNC for durability. os.O_CREATE and os.O_APPEND are
+// always added, and os.O_RDWR if flag contains neither os.O_WRONLY nor os.O_RDWR. os.O_EXCL and os.O_TRUNC
+// only apply to the first open and to the new file after a rotation; reopening a file this RollingFile
+// already wrote to (see WithIdleRelease, WithRotationRetry and WithFallbackPath) continues it.
+// WithMmap requires a readable file and falls back to regular writes with os.O_WRONLY.
+func WithOpenFlags(flag int) Option {
+	return func(w *RollingFile) {
+		if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+			flag |= os.O_RDWR
+		}
+		w.openFlags = flag
+	}
+}
+
+// WithWriterWrapper returns an option to wrap the active file with a decorator, e.g. a signer, an encoder or
+// a metrics-counting writer. wrap is called with the file, or the previous decorator if the option is given
+// multiple times, whenever a file is opened, including after every rotation. The decorators are closed
+// outermost first before the file is closed, so they can write out buffered data; they must not close
+// the writer they wrap. Decorators implementing Flush() error are flushed by Sync.
+func WithWriterWrapper(wrap func(io.Writer) io.WriteCloser) Option {
+	return func(w *RollingFile) {
+		w.wrappers = append(w.wrappers, wrap)
+	}
+}
+
+// WithBackupDir returns an option to place backups in dir instead of next to the log file, e.g. on a dedicated
+// archive volume. A relative dir is resolved against the directory of the log file, and dir is created if needed.
+// If dir is on another filesystem, backups are copied there (through a temporary file, synced before it is
+// renamed to its final name) and then removed from the log directory, which blocks writes during rotation.
+func WithBackupDir(dir string) Option {
+	return func(w *RollingFile) {
+		w.backupDir = dir
+	}
+}
+
+// WithCleanupBudget returns an option to bound every cleanup run of the backups by budget, so that a hung
+// network filesystem cannot block cleanup, and writes waiting for it in hard quota mode, forever. Cleanup stops
+// waiting for a filesystem operation once the budget is spent and emits CleanupIncomplete; the remaining work is
+// resumed by the cleanup after the next rotation. Stats reports whether the last cleanup was incomplete.
+func WithCleanupBudget(budget time.Duration) Option {
+	return func(w *RollingFile) {
+		w.cleanupBudget = budget
+	}
+}
+
+// WithFS returns an option to keep the log file, its backups and sidecar files on fsys instead of the filesystem
+// of the operating system, e.g. an in-memory filesystem in tests (see the rollingfiletest package). WithMmap and
+// WithDirectIO fall back to regular writes on it; WithExclusive, immutable backups and WithPostRotateCommand
+// cannot be used with it.
+func WithFS(fsys FS) Option {
+	return func(w *RollingFile) {
+		if fsys == nil {
+			w.optionErr = errors.New("WithFS requires a filesystem")
+			return
+		}
+		w.fs = fsys
+	}
+}
+
+// WithClock returns an option to take the current time from now instead of the system clock, for rotation
+// schedules, backup names and age-based retention. It allows deterministic tests of time-based rotation
+// (see the rollingfiletest package).
+func WithClock(now func() time.Time) Option {
+	return func(w *RollingFile) {
+		w.clock = now
+	}
+}
+
+// WithIdleRelease returns an option to close the file handle of the active file once it has not been written
+// to for idle, and to reopen it transparently on the next write. It keeps deployments with thousands of rarely
+// written logs under the file descriptor limit.
+func WithIdleRelease(idle time.Duration) Option {
+	return func(w *RollingFile) {
+		w.idleRelease = idle
+	}
+}
+
+// WithRotationRateLimit returns an option to allow at most n rotations per window, so a pathological burst or
+// a misconfigured tiny maximum size can't grind the service down with constant renames and cleanups. While the
+// cap is engaged, the active file exceeds its limits and a RotationThrottled event is emitted.
+func WithRotationRateLimit(n int, window time.Duration) Option {
+	return func(w *RollingFile) {
+		if n <= 0 || window <= 0 {
+			w.optionErr = fmt.Errorf("invalid rotation rate limit of %d per %v", n, window)
+			return
+		}
+		w.rotationLimit = n
+		w.rotationWindow = window
+	}
+}
+
+// WithRotationRetry returns an option to keep logging when a rotation fails, e.g. on a rename error or ENOSPC,
+// instead of failing the write. The original file is reopened and written to beyond its limits, and rotation
+// is retried after backoff, doubling with every failure up to 5 minutes. If the file cannot be reopened, up to
+// queueBytes of writes are queued in memory and written once it can. Failed rotations are passed to the
+// error handler. WithFallbackPath takes precedence if it is set.
+func WithRotationRetry(backoff time.Duration, queueBytes int) Option {
+	return func(w *RollingFile) {
+		if backoff <= 0 {
+			w.optionErr = fmt.Errorf("rotation retry backoff must be positive, got %v", backoff)
+			return
+		}
+		w.rotationRetry = backoff
+		w.retryQueueBytes = queueBytes
+	}
+}
+
+// WithFallbackPath returns an option to write to a secondary path when the primary path becomes unwritable,
+// e.g. after a read-only remount or a permission change. A marker line is written whenever the file switches,
+// and switching back to the primary path is attempted periodically on write.
+func WithFallbackPath(path string) Option {
+	return func(w *RollingFile) {
+		w.fallbackPath = path
+	}
+}
+
+// WithRotationStream returns an option to hand the content of every rotated file to fn, e.g. to stream it
+// directly to object storage. fn is called in the background with the backup path and a reader over its content,
+// before the backup is compressed. If discard is true and fn returns nil, the local backup is deleted afterwards.
+// Errors returned by fn are passed to the error handler and the backup is kept.
+func WithRotationStream(fn func(backupPath string, r io.Reader) error, discard bool) Option {
+	return func(w *RollingFile) {
+		w.streamFunc = fn
+		w.streamDiscard = discard
+	}
+}
+
+// WithForwarding returns an option to replay the lines of every rotated file to a syslog (RFC 5424) or plain
+// TCP endpoint in the background, before the backup is streamed or compressed. Sends are rate limited and
+// retried as configured; errors are passed to the error handler and the backup is kept. Close waits for
+// forwarding in progress. New fails if the configuration is invalid.
+func WithForwarding(cfg ForwardConfig) Option {
+	return func(w *RollingFile) {
+		cfg, err := cfg.withDefaults()
+		if err != nil {
+			w.optionErr = err
+			return
+		}
+		w.forward = &cfg
+	}
+}
+
+// WithMetadata returns an option to write a JSON sidecar ("<backup>.meta.json") next to every backup,
+// recording the times of its first and last write, its line count, size, SHA-256 checksum and
+// compression codec (see BackupMetadata). Sidecars are deleted together with their backups.
+func WithMetadata() Option {
+	return func(w *RollingFile) {
+		w.metadata = true
+	}
+}
@@ -0,0 +1,24 @@
+package rollingfile
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// preallocate extends file to size, reserving the disk space so that writes through a mapping
+// cannot fail with SIGBUS when the filesystem runs full.
+func preallocate(file *os.File, size int64) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if size <= info.Size() {
+		return nil
+	}
+	// Filesystems without fallocate are not emulated with Truncate, which would leave the file sparse.
+	if err := syscall.Fallocate(int(file.Fd()), 0, info.Size(), size-info.Size()); err != nil {
+		return fmt.Errorf("failed to preallocate %s: %w", file.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package rollingfile
+
+import "os"
+
+// preallocate is not supported on this platform. Extending the file with Truncate instead would leave
+// it sparse, so writes through a mapping could still fail with SIGBUS.
+func preallocate(file *os.File, size int64) error {
+	return errMmapUnsupported
+}
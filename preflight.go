@@ -0,0 +1,66 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errDiskSpaceUnsupported is returned by availableBytes on platforms without statfs.
+var errDiskSpaceUnsupported = errors.New("disk space check not supported")
+
+// runPreflight verifies that the directory of path is writable, that files can be created and renamed in it,
+// and that it has enough free space for the configured budget.
+func (l *RollingFile) runPreflight(path string) error {
+	dir := filepath.Dir(path)
+	info, err := l.fs.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("log directory %q is not accessible: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("log directory %q is not a directory", dir)
+	}
+
+	probe := filepath.Join(dir, fmt.Sprintf(".%s.preflight-%d", filepath.Base(path), os.Getpid()))
+	f, err := l.fs.OpenFile(probe, os.O_CREATE|os.O_EXCL|os.O_WRONLY, l.mode)
+	if err != nil {
+		return fmt.Errorf("cannot create files in log directory %q (check permissions and mount options): %w", dir, err)
+	}
+	f.Close()
+	renamed := probe + ".renamed"
+	if err := l.fs.Rename(probe, renamed); err != nil {
+		l.fs.Remove(probe)
+		return fmt.Errorf("cannot rename files in log directory %q, rotation would fail: %w", dir, err)
+	}
+	if err := l.fs.Remove(renamed); err != nil {
+		return fmt.Errorf("cannot remove files in log directory %q, backup cleanup would fail: %w", dir, err)
+	}
+
+	required := l.requiredBytes()
+	if required <= 0 || !l.onOSFS() {
+		return nil
+	}
+	available, err := availableBytes(dir)
+	if errors.Is(err, errDiskSpaceUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot determine free space in log directory %q: %w", dir, err)
+	}
+	if available < uint64(required) {
+		return fmt.Errorf("insufficient free space in log directory %q: %d bytes available, configuration may use up to %d bytes (reduce the size limits or free up space)", dir, available, required)
+	}
+	return nil
+}
+
+// requiredBytes returns the maximum disk usage allowed by the configuration, or 0 if it is unbounded.
+func (l *RollingFile) requiredBytes() int64 {
+	if l.maxTotalBytes > 0 {
+		return l.maxTotalBytes
+	}
+	if l.maxSize > 0 && l.maxBackups > 0 {
+		return l.maxSize * int64(l.maxBackups+1)
+	}
+	return l.maxSize
+}
@@ -0,0 +1,42 @@
+package rollingfile
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPreflightPasses verifies that preflight succeeds for a writable directory and leaves no probe files.
+func TestPreflightPasses(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := New(filepath.Join(tmpDir, "preflight.log"),
+		WithMaxBytes(1024),
+		WithMaxBackups(3),
+		WithPreflight(),
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	entries, err := os.ReadDir(tmpDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// TestPreflightFails ensures that preflight reports a missing directory and an unsatisfiable budget.
+func TestPreflightFails(t *testing.T) {
+	tmpDir := t.TempDir()
+	_, err := New(filepath.Join(tmpDir, "missing", "preflight.log"), WithPreflight())
+	assert.ErrorContains(t, err, "not accessible")
+
+	if _, err := availableBytes(tmpDir); err != nil {
+		t.Skip("disk space check not supported:", err)
+	}
+	_, err = New(filepath.Join(tmpDir, "preflight.log"),
+		WithMaxTotalBytes(math.MaxInt64),
+		WithPreflight(),
+	)
+	assert.ErrorContains(t, err, "insufficient free space")
+}
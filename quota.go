@@ -0,0 +1,76 @@
+package rollingfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrQuotaExceeded is returned by Write in hard quota mode when a write would exceed
+// the total size budget and no more backups can be deleted to make room.
+var ErrQuotaExceeded = errors.New("disk quota exceeded")
+
+// enforceTotalBytes deletes the oldest of the given backups until their total size plus reserve
+// fits into maxTotalBytes, and records the size of the remaining backups.
+// The caller must hold the cleanup mutex. It gives up once ctx is done.
+func (l *RollingFile) enforceTotalBytes(ctx context.Context, backups []string, reserve int64) {
+	sizes := make([]int64, len(backups))
+	var total int64
+	for i, file := range backups {
+		var info os.FileInfo
+		err := bounded(ctx, func() (err error) {
+			info, err = l.fs.Stat(file)
+			return err
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		sizes[i] = info.Size()
+		total += sizes[i]
+	}
+
+	for i, file := range backups {
+		if total+reserve <= l.maxTotalBytes {
+			break
+		}
+		if err := bounded(ctx, func() error { return l.removeBackup(file) }); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			l.cleanupError(fmt.Errorf("failed to remove backup file %q: %w", file, err))
+			continue
+		}
+		total -= sizes[i]
+	}
+	l.backupBytes.Store(total)
+}
+
+// checkQuota verifies that writing n more bytes keeps the active file and its backups
+// within maxTotalBytes, deleting the oldest backups synchronously if necessary.
+func (l *RollingFile) checkQuota(n int64) error {
+	if l.size+n+l.backupBytes.Load() <= l.maxTotalBytes {
+		return nil
+	}
+
+	l.cleanupMutex.Lock()
+	defer l.cleanupMutex.Unlock()
+	ctx, cancel := l.cleanupContext()
+	defer cancel()
+	var backups []string
+	err := bounded(ctx, func() (err error) {
+		backups, err = l.listBackups(l.file.Name())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list backup files: %w", err)
+	}
+	l.enforceTotalBytes(ctx, backups, l.size+n)
+	if l.size+n+l.backupBytes.Load() > l.maxTotalBytes {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
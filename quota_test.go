@@ -0,0 +1,89 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMaxTotalBytesIsEnforced verifies that cleanup keeps backups within the total budget.
+func TestMaxTotalBytesIsEnforced(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "budget.log")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMaxTotalBytes(250),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("b", 80) + "\n"
+	for i := 0; i < 6; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, int64(len(msg)), logger.Stats().BackupBytes)
+}
+
+// TestHardQuotaRejectsWrites ensures that Write fails with ErrQuotaExceeded once
+// the budget is exhausted and nothing can be deleted.
+func TestHardQuotaRejectsWrites(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "quota.log")
+	logger, err := New(logPath,
+		WithMaxTotalBytes(100),
+		WithHardQuota(),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("q", 59) + "\n"
+	_, err = logger.Write([]byte(msg))
+	assert.NoError(t, err)
+	n, err := logger.Write([]byte(msg))
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, 0, n)
+
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, string(contents))
+}
+
+// TestHardQuotaDeletesBackups ensures that existing backups are deleted to make room
+// before a write is rejected.
+func TestHardQuotaDeletesBackups(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "quota.log")
+	backupPath := logPath + ".20240101-000000.0"
+	err := os.WriteFile(backupPath, []byte(strings.Repeat("o", 80)), 0644)
+	assert.NoError(t, err)
+
+	logger, err := New(logPath,
+		WithMaxTotalBytes(100),
+		WithHardQuota(),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(80), logger.Stats().BackupBytes)
+
+	_, err = logger.Write([]byte(strings.Repeat("n", 49) + "\n"))
+	assert.NoError(t, err)
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	_, err = os.Stat(backupPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// TestHardQuotaRequiresBudget verifies that hard quota mode without a total budget is rejected
+// instead of deleting every backup and failing every write.
+func TestHardQuotaRequiresBudget(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "quota.log"), WithHardQuota())
+	assert.ErrorContains(t, err, "WithHardQuota requires a positive WithMaxTotalBytes budget")
+}
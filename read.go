@@ -0,0 +1,70 @@
+package rollingfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// snapshotReader reads a fixed-size prefix of a file.
+type snapshotReader struct {
+	*io.SectionReader
+	file File
+}
+
+func (r *snapshotReader) Close() error {
+	return r.file.Close()
+}
+
+// ReadCurrent returns a reader over the content of the active file at the time of the call.
+// The snapshot is taken while no write or rotation is in progress and stays consistent afterwards:
+// the reader keeps its own handle on the file, so a subsequent rotation does not truncate or swap
+// the content being read, and data written later is not included. The caller must close the reader.
+func (l *RollingFile) ReadCurrent() (io.ReadCloser, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		// Not opened yet (see WithLazyOpen) or released (see WithIdleRelease),
+		// the file holds what was written, if anything.
+		file, err := l.open(l.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return io.NopCloser(bytes.NewReader(nil)), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file for reading: %w", err)
+		}
+		return file, nil
+	}
+	if err := l.flushFile(); err != nil {
+		return nil, fmt.Errorf("failed to flush log file: %w", err)
+	}
+	fdInfo, err := l.file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	file, err := l.open(l.file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file for reading: %w", err)
+	}
+	pathInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	if !sameFile(fdInfo, pathInfo) {
+		file.Close()
+		return nil, errors.New("log file path no longer refers to the open file")
+	}
+	size := fdInfo.Size()
+	if l.mapping != nil {
+		// The file extends beyond the written data into the preallocated mapping.
+		size = l.size
+	}
+	return &snapshotReader{
+		SectionReader: io.NewSectionReader(file, 0, size),
+		file:          file,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package rollingfile
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReadCurrentSnapshot verifies that the reader sees the content at the time of the call,
+// unaffected by later writes and rotations.
+func TestReadCurrentSnapshot(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "read.log")
+	logger, err := New(logPath, WithMaxBytes(100))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	first := strings.Repeat("1", 59) + "\n"
+	_, err = logger.Write([]byte(first))
+	assert.NoError(t, err)
+
+	reader, err := logger.ReadCurrent()
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	// Appends to the same file and then forces a rotation.
+	_, err = logger.Write([]byte("2\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte(strings.Repeat("3", 59) + "\n"))
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, first, string(data))
+}
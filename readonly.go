@@ -0,0 +1,49 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// readOnlyMode is the mode of backups with WithReadOnlyBackups.
+const readOnlyMode = 0440
+
+// errImmutableUnsupported is returned by setImmutable on platforms without an immutable file attribute.
+var errImmutableUnsupported = errors.New("immutable attribute not supported")
+
+// backupFileMode returns the mode of backups set with WithBackupMode, without write permissions
+// with WithReadOnlyBackups. It returns 0 if backups keep the mode of the active file.
+func (l *RollingFile) backupFileMode() os.FileMode {
+	switch {
+	case l.backupMode != 0 && l.readOnlyBackups:
+		return l.backupMode &^ 0222
+	case l.backupMode != 0:
+		return l.backupMode
+	case l.readOnlyBackups:
+		return readOnlyMode
+	}
+	return 0
+}
+
+// protectBackup applies the backup mode to a new backup file (see WithBackupMode and WithReadOnlyBackups)
+// and, if enabled, makes it immutable. Failures are reported to the error handler, they do not fail the rotation.
+func (l *RollingFile) protectBackup(path string) {
+	if mode := l.backupFileMode(); mode != 0 {
+		if err := l.fs.Chmod(path, mode); err != nil {
+			l.errorHandler(fmt.Errorf("failed to set the mode of backup file %q: %w", path, err))
+		}
+	}
+	if l.immutableBackups {
+		if err := setImmutable(path, true); err != nil && !errors.Is(err, errImmutableUnsupported) {
+			l.errorHandler(fmt.Errorf("failed to make backup file %q immutable: %w", path, err))
+		}
+	}
+}
+
+// unprotectBackup clears the immutable attribute of a backup, so that it can be removed by compression or cleanup.
+func (l *RollingFile) unprotectBackup(path string) {
+	if l.immutableBackups {
+		setImmutable(path, false)
+	}
+}
@@ -0,0 +1,115 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReadOnlyBackups verifies that backups are read-only after rotation and compression and can still be
+// compressed and removed by retention.
+func TestReadOnlyBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	logger, err := New(logPath, WithMaxBytes(10), WithMaxBackups(2), WithReadOnlyBackups(false), WithMode(0644))
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = logger.Write([]byte("line\n"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	backups, err := logger.listBackups(logPath)
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	for _, backup := range backups {
+		info, err := os.Stat(backup)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(readOnlyMode), info.Mode().Perm())
+	}
+	info, err := os.Stat(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+// TestReadOnlyCompressedBackups verifies that compressed backups are read-only as well.
+func TestReadOnlyCompressedBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	logger, err := New(logPath, WithMaxBytes(10), WithCompression(), WithReadOnlyBackups(false))
+	assert.NoError(t, err)
+	logger.Write([]byte("first\n"))
+	logger.Write([]byte("second\n"))
+	assert.NoError(t, logger.Close())
+
+	backups, err := filepath.Glob(logPath + ".*" + compressedSuffix)
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		info, err := os.Stat(backups[0])
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(readOnlyMode), info.Mode().Perm())
+	}
+}
+
+// TestImmutableBackups verifies that immutable backups can still be removed by retention.
+// It is skipped where the immutable attribute cannot be set.
+func TestImmutableBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	probe := filepath.Join(tmpDir, "probe")
+	assert.NoError(t, os.WriteFile(probe, nil, 0644))
+	if err := setImmutable(probe, true); err != nil {
+		t.Skipf("immutable attribute not available: %v", err)
+	}
+	assert.Error(t, os.Remove(probe))
+	assert.NoError(t, setImmutable(probe, false))
+
+	logPath := filepath.Join(tmpDir, "app.log")
+	logger, err := New(logPath, WithMaxBytes(10), WithMaxBackups(1), WithReadOnlyBackups(true))
+	assert.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		logger.Write([]byte("line\n"))
+	}
+	assert.NoError(t, logger.Close())
+	backups, err := logger.listBackups(logPath)
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+	for _, backup := range backups {
+		setImmutable(backup, false)
+	}
+}
+
+// TestBackupMode verifies that backups get the backup mode while the active file keeps its mode,
+// and that read-only backups remove the write permissions from it.
+func TestBackupMode(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		options  []Option
+		expected os.FileMode
+	}{
+		{"backup mode", []Option{WithBackupMode(0600)}, 0600},
+		{"read-only backup mode", []Option{WithBackupMode(0640), WithReadOnlyBackups(false)}, 0440},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			logPath := filepath.Join(tmpDir, "app.log")
+			logger, err := New(logPath, append(tt.options, WithMaxBytes(10), WithMode(0644), WithCompression())...)
+			assert.NoError(t, err)
+			logger.Write([]byte("first\n"))
+			logger.Write([]byte("second\n"))
+			assert.NoError(t, logger.Close())
+
+			backups, err := logger.listBackups(logPath)
+			assert.NoError(t, err)
+			if assert.Len(t, backups, 1) {
+				info, err := os.Stat(backups[0])
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, info.Mode().Perm())
+			}
+			info, err := os.Stat(logPath)
+			assert.NoError(t, err)
+			assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+		})
+	}
+}
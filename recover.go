@@ -0,0 +1,107 @@
+package rollingfile
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recoverState initializes the rotation state from the backups already on disk, so that naming
+// and retention behave correctly from the first rotation after a restart: the total backup size,
+// the time of the last rotation and, with a persistent sequence, the highest sequence number in use
+// (in case the sidecar file was lost). Temporary files left behind by an interrupted compression or copy
+// to the backup directory are removed, as are empty backups, which are names reserved by a rotation that
+// was interrupted before the file was renamed onto them (see reserveBackup).
+func (l *RollingFile) recoverState() error {
+	name := l.activeName()
+	prefix := l.backupPrefix(name)
+	matches, err := l.fs.Glob(prefix + ".*" + tmpSuffix)
+	if err != nil {
+		return err
+	}
+	for _, file := range matches {
+		if isBackupTemp(prefix, file, l.compressionExt()) {
+			l.fs.Remove(file)
+		}
+	}
+
+	backups, err := l.listBackups(name)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	var lastRotation time.Time
+	for _, file := range backups {
+		info, err := l.fs.Stat(file)
+		if err == nil && info.Size() == 0 && backupNameRegexp.MatchString(strings.TrimPrefix(file, prefix)) {
+			l.fs.Remove(file)
+			continue
+		}
+		if err == nil {
+			total += info.Size()
+		}
+		if m := backupTimestampRegexp.FindStringSubmatch(file); len(m) == 2 {
+			if ts, err := time.ParseInLocation(backupTimeLayout, m[1], time.Local); err == nil && ts.After(lastRotation) {
+				lastRotation = ts
+			}
+		}
+		if seq, ok := backupSequence(l.backupPrefix(name), file); ok && seq > l.sequence {
+			l.sequence = seq
+		}
+	}
+	l.backupBytes.Store(total)
+	if !lastRotation.IsZero() {
+		l.lastRotation.Store(lastRotation.UnixNano())
+	}
+	return nil
+}
+
+// backupNameRegexp matches what follows the prefix in the names of backups created by uniqueBackupPath
+// and sequencedBackupPath, and by earlier versions without the sub-second counter.
+var backupNameRegexp = regexp.MustCompile(`^\.(?:\d{12}\.)?\d{8}-\d{6}(?:\.\d+)?$`)
+
+// isBackupTemp reports whether file is a temporary file of this package for a backup with the given prefix,
+// named "<backup><ext>.tmp" by compression or "<backup>.tmp" by copyBackup.
+func isBackupTemp(prefix, file, ext string) bool {
+	backup, ok := strings.CutSuffix(file, tmpSuffix)
+	if !ok {
+		return false
+	}
+	backup = strings.TrimSuffix(backup, ext)
+	rest, ok := strings.CutPrefix(backup, prefix)
+	return ok && backupNameRegexp.MatchString(rest)
+}
+
+// backupSequence extracts the sequence number from a backup named "<prefix>.<sequence>.<timestamp>".
+func backupSequence(prefix, backup string) (uint64, bool) {
+	field, _, _ := strings.Cut(strings.TrimPrefix(backup, prefix+"."), ".")
+	if len(field) != sequenceWidth {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(field, 10, 64)
+	return seq, err == nil
+}
+
+// RecoveryMarker is the line written by WithRecoveryMarker after a partial last line.
+const RecoveryMarker = "[previous process terminated uncleanly]\n"
+
+// recoverLastByte reads the last byte of the opened file, so that line-oriented transformations
+// continue correctly. With a recovery marker, a partial last line left behind by a crash is
+// terminated and followed by the marker.
+func (l *RollingFile) recoverLastByte() error {
+	if l.size == 0 {
+		return nil
+	}
+	var b [1]byte
+	if err := l.readActive(b[:], l.size-1); err != nil {
+		return err
+	}
+	l.lastByte = b[0]
+	// A partial line of a released file was written by this process.
+	if l.recoveryMarker && l.lastByte != '\n' && !l.released {
+		l.writeMarker(RecoveryMarker)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package rollingfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecoverStateFromBackups verifies that New initializes its state from existing backups.
+func TestRecoverStateFromBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "recover.log")
+	lastRotation := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	for i, name := range []string{
+		"000000000003.20240501-100000",
+		"000000000007.20240501-120000.gz",
+		"000000000007.20240501-120000.gz.tmp",
+	} {
+		err := os.WriteFile(logPath+"."+name, []byte(strings.Repeat("r", 10*(i+1))), 0644)
+		assert.NoError(t, err)
+	}
+
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithPersistentSequence(),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(7), logger.sequence)
+	assert.Equal(t, int64(30), logger.Stats().BackupBytes)
+	assert.True(t, lastRotation.Equal(logger.LastRotation()))
+	_, err = os.Stat(logPath + ".000000000007.20240501-120000.gz.tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	msg := []byte(strings.Repeat("r", 80) + "\n")
+	for i := 0; i < 2; i++ {
+		_, err := logger.Write(msg)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	files, err := filepath.Glob(logPath + ".000000000008.*")
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+// TestStartupKeepsFiles ensures that opening a file deletes neither backups beyond the retention
+// limits, which are only enforced after a rotation, nor temporary files of other programs.
+func TestStartupKeepsFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "startup.log")
+	var kept []string
+	for i := 0; i < 5; i++ {
+		kept = append(kept, fmt.Sprintf("%s.20240101-00000%d.0", logPath, i))
+	}
+	kept = append(kept, logPath+".upload.tmp", logPath+".20240101-000005.0.xz.tmp")
+	for _, file := range kept {
+		assert.NoError(t, os.WriteFile(file, []byte("old\n"), 0644))
+	}
+	interrupted := logPath + ".20240101-000006.0.gz.tmp"
+	assert.NoError(t, os.WriteFile(interrupted, []byte("partial"), 0644))
+
+	logger, err := New(logPath, WithMaxBackups(2), WithCompression())
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, kept, files)
+}
+
+// TestStartupRemovesReservedBackups verifies that empty backups, which are names reserved by a rotation
+// interrupted by a crash, are removed when the file is opened.
+func TestStartupRemovesReservedBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "reserved.log")
+	reserved := []string{logPath + ".20240101-000001.000000001", logPath + ".000000000002.20240101-000002"}
+	for _, file := range reserved {
+		assert.NoError(t, os.WriteFile(file, nil, 0644))
+	}
+	kept := []string{logPath + ".20240101-000000.000000001", logPath + ".bak"}
+	assert.NoError(t, os.WriteFile(kept[0], []byte("old\n"), 0644))
+	assert.NoError(t, os.WriteFile(kept[1], nil, 0644))
+
+	logger, err := New(logPath)
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, kept, files)
+	assert.Equal(t, int64(4), logger.Stats().BackupBytes)
+}
+
+// TestRecoveryMarkerAfterPartialLine verifies that a partial last line is terminated and marked on New,
+// and that files ending with a newline are left untouched.
+func TestRecoveryMarkerAfterPartialLine(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "crash.log")
+	assert.NoError(t, os.WriteFile(logPath, []byte("complete\npartial"), 0644))
+
+	logger, err := New(logPath, WithRecoveryMarker(), WithLinePrefix(func() []byte { return []byte("> ") }))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("next\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "complete\npartial\n"+RecoveryMarker+"> next\n", string(contents))
+
+	logger, err = New(logPath, WithRecoveryMarker())
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+	after, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, contents, after)
+}
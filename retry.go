@@ -0,0 +1,52 @@
+package rollingfile
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxRotationBackoff bounds the backoff between rotation attempts, unless the configured backoff is larger.
+const maxRotationBackoff = 5 * time.Minute
+
+// reopen opens the active file at name again after a failed rotation closed it, so that logging continues
+// to the original file. If it cannot be opened, the file is left unset and opened again by the next write.
+func (l *RollingFile) reopen(name string) {
+	file, size, err := l.openLogFile(name, true)
+	if err != nil {
+		l.errorHandler(fmt.Errorf("failed to reopen log file after failed rotation: %w", err))
+		l.file = nil
+		return
+	}
+	l.file, l.size = file, size
+	l.setupFile()
+}
+
+// deferRotation reports a failed rotation and postpones the next attempt by an exponentially growing backoff
+// (see WithRotationRetry). Meanwhile, writes continue to the active file beyond its limits.
+func (l *RollingFile) deferRotation(now time.Time, err error) {
+	l.rotationBackoff = min(max(2*l.rotationBackoff, l.rotationRetry), max(l.rotationRetry, maxRotationBackoff))
+	l.rotationRetryAt = now.Add(l.rotationBackoff)
+	l.errorHandler(fmt.Errorf("%w, retrying in %v", err, l.rotationBackoff))
+}
+
+// queueWrite keeps data in memory while no file is open, as long as it fits into the queue.
+func (l *RollingFile) queueWrite(data []byte) bool {
+	if len(l.pending)+len(data) > l.retryQueueBytes {
+		return false
+	}
+	l.pending = append(l.pending, data...)
+	return true
+}
+
+// flushPending writes the queued data to the active file.
+func (l *RollingFile) flushPending() error {
+	n, err := l.writeActive(l.pending)
+	if n > 0 {
+		l.lastByte = l.pending[n-1]
+	}
+	l.pending = l.pending[n:]
+	if len(l.pending) == 0 {
+		l.pending = nil
+	}
+	return err
+}
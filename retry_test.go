@@ -0,0 +1,119 @@
+package rollingfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRotationRetryBacksOff verifies that writes continue to the active file while rotation fails
+// and that rotation is retried after the backoff.
+func TestRotationRetryBacksOff(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	backupDir := filepath.Join(tmpDir, "backups")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	var errs []error
+	logger, err := New(logPath, WithMaxBytes(10), WithBackupDir(backupDir), WithRotationRetry(time.Minute, 0),
+		WithClock(func() time.Time { return now }), WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	assert.NoError(t, err)
+	defer logger.Close()
+	assert.NoError(t, os.Remove(backupDir))
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err = logger.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.Len(t, errs, 1, "rotation should only be attempted once during the backoff")
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\nthird\n", string(data))
+
+	assert.NoError(t, os.Mkdir(backupDir, 0755))
+	now = now.Add(time.Minute)
+	_, err = logger.Write([]byte("fourth\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, logger.Stats().Rotations)
+	data, err = os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "fourth\n", string(data))
+}
+
+// failingCloser fails to close once.
+type failingCloser struct {
+	io.Writer
+	fail *bool
+}
+
+func (c failingCloser) Close() error {
+	if *c.fail {
+		*c.fail = false
+		return errors.New("close failed")
+	}
+	return nil
+}
+
+// TestRotationRetryReopens verifies that the original file is reopened when a failed rotation closed it.
+func TestRotationRetryReopens(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	fail := true
+	logger, err := New(logPath, WithMaxBytes(10), WithRotationRetry(time.Hour, 0), WithErrorHandler(func(error) {}),
+		WithWriterWrapper(func(w io.Writer) io.WriteCloser { return failingCloser{w, &fail} }))
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.Error(t, logger.Healthy())
+	assert.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+}
+
+// TestRotationRetryQueuesWrites verifies that writes are queued while no file can be opened
+// and written once it can, and that writes beyond the queue size fail.
+func TestRotationRetryQueuesWrites(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	logPath := filepath.Join(dir, "app.log")
+	logger, err := New(logPath, WithLazyOpen(), WithRotationRetry(time.Second, 16))
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("overflow\n"))
+	assert.Error(t, err)
+
+	assert.NoError(t, os.Mkdir(dir, 0755))
+	_, err = logger.Write([]byte("third\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\nthird\n", string(data))
+}
+
+// TestRotationRetryCloseDropsQueue verifies that Close reports queued writes it cannot write.
+func TestRotationRetryCloseDropsQueue(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "logs", "app.log")
+	logger, err := New(logPath, WithLazyOpen(), WithRotationRetry(time.Second, 1024))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.EqualError(t, logger.Close(), "dropped 6 bytes of queued writes")
+}
+
+// TestRotationRetryInvalidBackoff verifies that a backoff must be positive.
+func TestRotationRetryInvalidBackoff(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "app.log"), WithRotationRetry(0, 0))
+	assert.ErrorContains(t, err, "backoff must be positive")
+}
@@ -0,0 +1,181 @@
+package rollingfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ringMagic identifies ring files and the version of their header.
+const ringMagic = "RFRING1\n"
+
+// ringHeaderSize is the size of the ring file header: the magic, the capacity of the data area,
+// the offset of the logical head within it and the number of bytes in use, as big-endian uint64s.
+const ringHeaderSize = int64(len(ringMagic)) + 3*8
+
+// RingFile is a log file of fixed size used as a circular buffer, an alternative to rotation for
+// embedded devices and flash media where creating and deleting files is undesirable.
+// Once full, new writes overwrite the oldest data. Use ReadRing to read it in chronological order.
+type RingFile struct {
+	mu       sync.Mutex
+	file     *os.File
+	capacity int64
+	head     int64
+	used     int64
+}
+
+// NewRing opens or creates the ring file at path with a data area of capacity bytes, preallocating it.
+// An existing ring file is continued at its logical head; it fails if it was created with a different capacity.
+func NewRing(path string, capacity int64) (*RingFile, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid ring capacity %d", capacity)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ring file: %w", err)
+	}
+	r := &RingFile{file: file, capacity: capacity}
+	if err := r.init(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// init reads the header of an existing ring file or initializes a new one.
+func (r *RingFile) init() error {
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		if err := r.file.Truncate(ringHeaderSize + r.capacity); err != nil {
+			return fmt.Errorf("failed to preallocate ring file: %w", err)
+		}
+		return r.writeHeader()
+	}
+	capacity, head, used, err := readRingHeader(r.file)
+	if err != nil {
+		return err
+	}
+	if capacity != r.capacity {
+		return fmt.Errorf("ring file %q has capacity %d, not %d", r.file.Name(), capacity, r.capacity)
+	}
+	r.head, r.used = head, used
+	return nil
+}
+
+// readRingHeader reads and validates the header of a ring file.
+func readRingHeader(file *os.File) (capacity, head, used int64, err error) {
+	var header [ringHeaderSize]byte
+	if _, err := file.ReadAt(header[:], 0); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to read ring header: %w", err)
+	}
+	if !bytes.Equal(header[:len(ringMagic)], []byte(ringMagic)) {
+		return 0, 0, 0, fmt.Errorf("%q is not a ring file", file.Name())
+	}
+	fields := header[len(ringMagic):]
+	capacity = int64(binary.BigEndian.Uint64(fields[0:]))
+	head = int64(binary.BigEndian.Uint64(fields[8:]))
+	used = int64(binary.BigEndian.Uint64(fields[16:]))
+	if capacity <= 0 || head < 0 || head >= capacity || used < 0 || used > capacity {
+		return 0, 0, 0, fmt.Errorf("corrupted ring header in %q", file.Name())
+	}
+	return capacity, head, used, nil
+}
+
+// writeHeader persists the logical head. It is written after the data, so a crash in between
+// loses at most the last write.
+func (r *RingFile) writeHeader() error {
+	var header [ringHeaderSize]byte
+	copy(header[:], ringMagic)
+	fields := header[len(ringMagic):]
+	binary.BigEndian.PutUint64(fields[0:], uint64(r.capacity))
+	binary.BigEndian.PutUint64(fields[8:], uint64(r.head))
+	binary.BigEndian.PutUint64(fields[16:], uint64(r.used))
+	_, err := r.file.WriteAt(header[:], 0)
+	return err
+}
+
+// Write writes p at the logical head, wrapping around to overwrite the oldest data when the end
+// of the data area is reached. Writes larger than the capacity are rejected.
+func (r *RingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if int64(len(p)) > r.capacity {
+		return 0, fmt.Errorf("write of %d bytes exceeds ring capacity %d", len(p), r.capacity)
+	}
+	for written := 0; written < len(p); {
+		chunk := p[written:]
+		if room := r.capacity - r.head; int64(len(chunk)) > room {
+			chunk = chunk[:room]
+		}
+		if _, err := r.file.WriteAt(chunk, ringHeaderSize+r.head); err != nil {
+			return 0, err
+		}
+		written += len(chunk)
+		r.head = (r.head + int64(len(chunk))) % r.capacity
+	}
+	r.used = min(r.used+int64(len(p)), r.capacity)
+	if err := r.writeHeader(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sync calls the Sync function on the underlying file.
+func (r *RingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+// Close closes the underlying file.
+func (r *RingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// ReadAll returns the content of the ring in chronological order, see ReadRing.
+func (r *RingFile) ReadAll() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return readRing(r.file, r.capacity, r.head, r.used)
+}
+
+// ReadRing reads the ring file at path and returns its content in chronological order, oldest first.
+// Once the ring has wrapped around, the oldest line is usually partially overwritten and is dropped.
+func ReadRing(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	capacity, head, used, err := readRingHeader(file)
+	if err != nil {
+		return nil, err
+	}
+	return readRing(file, capacity, head, used)
+}
+
+// readRing reads the used part of the data area, starting at the logical head once the ring has wrapped around.
+func readRing(file *os.File, capacity, head, used int64) ([]byte, error) {
+	data := make([]byte, used)
+	if used < capacity {
+		_, err := file.ReadAt(data, ringHeaderSize)
+		return data, err
+	}
+	if _, err := file.ReadAt(data[:capacity-head], ringHeaderSize+head); err != nil {
+		return nil, err
+	}
+	if _, err := file.ReadAt(data[capacity-head:], ringHeaderSize); err != nil {
+		return nil, err
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return data, nil
+}
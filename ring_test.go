@@ -0,0 +1,52 @@
+package rollingfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRingFileWrapsAround verifies that the ring keeps a fixed size, overwrites the oldest lines
+// and is read back in chronological order, also after reopening.
+func TestRingFileWrapsAround(t *testing.T) {
+	ringPath := filepath.Join(t.TempDir(), "ring.log")
+	ring, err := NewRing(ringPath, 32)
+	assert.NoError(t, err)
+
+	_, err = ring.Write([]byte("line 0\nline 1\n"))
+	assert.NoError(t, err)
+	data, err := ring.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "line 0\nline 1\n", string(data))
+
+	for i := 2; i < 10; i++ {
+		_, err := fmt.Fprintf(ring, "line %d\n", i)
+		assert.NoError(t, err)
+	}
+	_, err = ring.Write(make([]byte, 33))
+	assert.Error(t, err)
+	assert.NoError(t, ring.Close())
+
+	info, err := os.Stat(ringPath)
+	assert.NoError(t, err)
+	assert.Equal(t, ringHeaderSize+32, info.Size())
+
+	data, err = ReadRing(ringPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 6\nline 7\nline 8\nline 9\n", string(data))
+
+	ring, err = NewRing(ringPath, 32)
+	assert.NoError(t, err)
+	_, err = ring.Write([]byte("line 10\n"))
+	assert.NoError(t, err)
+	data, err = ring.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "line 7\nline 8\nline 9\nline 10\n", string(data))
+	assert.NoError(t, ring.Close())
+
+	_, err = NewRing(ringPath, 64)
+	assert.Error(t, err, "capacity must match the existing ring file")
+}
@@ -1,156 +1,741 @@
 package rollingfile
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	compressedSuffix = ".gz"
+	tmpSuffix        = ".tmp"
+	backupTimeLayout = "20060102-150405"
+)
+
+var backupTimestampRegexp = regexp.MustCompile(`\.(\d{8}-\d{6})(?:\.|$)`)
+
 type RollingFile struct {
-	maxBackups       int
-	maxSize          int64
-	maxAge           time.Duration
-	file             *os.File
-	size             int64
-	mode             os.FileMode
-	errorHandler     func(error)
-	cleanupMutex     sync.Mutex
-	cleanupWaitGroup sync.WaitGroup
+	mu                     sync.Mutex
+	path                   string
+	maxBackups             int
+	maxUncompressedBackups int
+	maxCompressedBackups   int
+	minBackups             int
+	maxSize                int64
+	maxAge                 time.Duration
+	maxDays                int
+	file                   File
+	size                   int64
+	mode                   os.FileMode
+	fs                     FS
+	errorHandler           func(error)
+	optionErr              error
+	preflight              bool
+	lineEnding             LineEnding
+	linePrefix             func() []byte
+	truncateOversized      bool
+	tees                   []io.Writer
+
+	persistentSequence bool
+	sequence           uint64
+
+	rotationInterval time.Duration
+	trigger          bool
+	triggerPath      string
+	triggerInterval  time.Duration
+	lastTriggerCheck time.Time
+	rotationJitter   time.Duration
+	lastRotation     atomic.Int64
+	nextRotation     atomic.Int64
+
+	maxTotalBytes int64
+	hardQuota     bool
+	backupBytes   atomic.Int64
+
+	eventFunc    func(Event)
+	queuedEvents []Event
+	clock        func() time.Time
+	rotations    atomic.Int64
+
+	nearLimitPercent    int
+	nearLimitFunc       func(NearLimit)
+	queuedNearLimits    []NearLimit
+	nearMaxSizeNotified bool
+	nearTotalNotified   bool
+
+	idleRelease           time.Duration
+	idleTimer             *time.Timer
+	released              bool
+	lastActivity          time.Time
+	rotationLimit         int
+	rotationWindow        time.Duration
+	recentRotations       []time.Time
+	throttleNotified      bool
+	rotationRetry         time.Duration
+	rotationBackoff       time.Duration
+	rotationRetryAt       time.Time
+	retryQueueBytes       int
+	pending               []byte
+	fallbackPath          string
+	fallbackRetryInterval time.Duration
+	onFallback            bool
+	lastFailbackAttempt   time.Time
+	jsonLines             bool
+	header                func() []byte
+	backupMode            os.FileMode
+	strictMode            bool
+	diskBudget            *DiskBudget
+	readOnlyBackups       bool
+	immutableBackups      bool
+	framed                bool
+	quarantinePath        string
+	quarantineFile        File
+	lastByte              byte
+	writeHealth           healthWindow
+	rotationHealth        healthWindow
+	cleanupMutex          sync.Mutex
+	cleanupWaitGroup      sync.WaitGroup
+
+	compress             bool
+	compressCommand      []string
+	compressExt          string
+	compressionWorkers   int
+	compressionQueueSize int
+	compressionQueue     chan string
+	compressionWaitGroup sync.WaitGroup
+	compressionPending   sync.Map
+	postRotatePending    sync.Map
+	compressionSkipped   sync.Map
+	compressionQueued    atomic.Int64
+
+	postRotateCommand []string
+	postRotateTimeout time.Duration
+	streamFunc        func(string, io.Reader) error
+	streamDiscard     bool
+	forward           *ForwardConfig
+	metadata          bool
+	contentStart      time.Time
+	contentEnd        time.Time
+
+	exclusive      bool
+	lockFile       File
+	recoveryMarker bool
+	lazyOpen       bool
+	openFlags      int
+	opened         bool
+
+	wrappers []func(io.Writer) io.WriteCloser
+	chain    []io.WriteCloser
+
+	backupDir           string
+	cleanupBudget       time.Duration
+	cleanupIncomplete   atomic.Bool
+	errorHistory        errorHistory
+	lastBackupTimestamp string
+	lastBackupCounter   int
+
+	mmapChunk        int64
+	mmapSyncInterval time.Duration
+	mapping          []byte
+	mappingBase      int64
+	lastMsync        time.Time
+
+	directBuffer int
+	direct       *os.File
+	directBuf    []byte
+	directOff    int64
+	directLen    int
+	directTail   int // bytes of the partial last block already in the file
+}
+
+// Stats is a point-in-time snapshot of a RollingFile's internal state.
+type Stats struct {
+	// CompressionQueueDepth is the number of backups waiting to be compressed.
+	CompressionQueueDepth int
+	// BackupBytes is the total size of retained backups. It is only tracked
+	// when a total size budget is configured with WithMaxTotalBytes.
+	BackupBytes int64
+	// Rotations is the number of rotations performed by this RollingFile.
+	Rotations int64
+	// CleanupIncomplete reports whether the last cleanup run exceeded its time budget (see WithCleanupBudget).
+	CleanupIncomplete bool
 }
 
 func (l *RollingFile) Write(line []byte) (n int, err error) {
 	if len(line) == 0 {
 		return 0, nil
 	}
-	n = len(line)
+	l.mu.Lock()
+	defer l.unlock()
+
+	if l.jsonLines {
+		if err = validateJSONLine(line); err != nil {
+			if l.quarantinePath == "" {
+				return 0, err
+			}
+			if err = l.quarantine(line); err != nil {
+				return 0, err
+			}
+			return len(line), nil
+		}
+	}
+	var data []byte
+	if l.framed {
+		data = appendFrame(make([]byte, 0, len(line)+binary.MaxVarintLen64), line)
+	} else {
+		data = l.transform(line)
+	}
+	if int64(len(data)) > l.maxSize && l.maxSize > 0 && l.truncateOversized {
+		data = truncateLine(data, l.maxSize)
+	}
+	n = len(data)
 	if int64(n) > l.maxSize && l.maxSize > 0 {
 		return 0, fmt.Errorf("line exceeds max size")
 	}
 
-	if l.size+int64(n) >= l.maxSize && l.maxSize > 0 {
-		if err = l.rotate(); err != nil {
+	if l.file == nil {
+		if err = l.openActive(); err != nil {
+			if l.rotationRetry > 0 && l.queueWrite(data) {
+				return len(line), nil
+			}
+			return 0, err
+		}
+	}
+	if len(l.pending) > 0 {
+		if err = l.flushPending(); err != nil {
+			return 0, fmt.Errorf("failed to write queued data: %w", err)
+		}
+	}
+	if l.onFallback {
+		l.tryFailback()
+	}
+
+	now := l.now()
+	header := l.pendingHeader()
+	if err = l.checkHeaderFits(header, n); err != nil {
+		return 0, err
+	}
+	triggered := l.rotationTriggered(now)
+	rotate := (l.size+int64(len(header)+n) >= l.maxSize && l.maxSize > 0) || l.rotationDue(now) || triggered
+	if rotate && now.Before(l.rotationRetryAt) {
+		// Backing off after a failed rotation, the file temporarily exceeds its limits.
+		rotate = false
+	}
+	if rotate && l.rotationThrottled(now) {
+		rotate = false
+	}
+	if rotate {
+		err = l.rotate()
+		l.rotationHealth.record(err)
+		switch {
+		case err == nil:
+			l.rotationBackoff = 0
+			l.recordRotation(now)
+		case l.fallbackPath != "" && !l.onFallback:
+			err = fmt.Errorf("failed to rotate log file: %w", err)
+			if ferr := l.failover(err); ferr != nil {
+				return 0, ferr
+			}
+		case l.rotationRetry > 0:
+			l.deferRotation(now, fmt.Errorf("failed to rotate log file: %w", err))
+			if l.file == nil {
+				if l.queueWrite(data) {
+					return len(line), nil
+				}
+				return 0, fmt.Errorf("failed to rotate log file: %w", err)
+			}
+		default:
 			return 0, fmt.Errorf("failed to rotate log file: %w", err)
 		}
+		header = l.pendingHeader()
+		if err = l.checkHeaderFits(header, n); err != nil {
+			return 0, err
+		}
 	}
 
-	n, err = l.file.Write(line)
+	if l.hardQuota {
+		if err = l.checkQuota(int64(len(header) + n)); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(header) > 0 {
+		if err = l.writeHeader(header); err != nil {
+			return 0, fmt.Errorf("failed to write header: %w", err)
+		}
+	}
+
+	n, err = l.writeActive(data)
+	if err != nil && n == 0 && l.fallbackPath != "" && !l.onFallback {
+		if ferr := l.failover(err); ferr != nil {
+			return 0, ferr
+		}
+		n, err = l.writeActive(data)
+	}
+	l.writeHealth.record(err)
+	l.touchIdle()
+	if n > 0 && l.metadata {
+		if l.contentStart.IsZero() {
+			l.contentStart = now
+		}
+		l.contentEnd = now
+	}
+	if n > 0 {
+		l.lastByte = data[n-1]
+	}
 	if err != nil {
-		return n, err
+		return min(n, len(line)), err
+	}
+	if l.nearLimitFunc != nil {
+		l.checkNearLimit()
+	}
+	for _, w := range l.tees {
+		if _, err := w.Write(data); err != nil {
+			l.errorHandler(fmt.Errorf("failed to write to tee: %w", err))
+		}
 	}
-	l.size += int64(n)
 
-	return n, nil
+	return len(line), nil
 }
 
 // rotate creates a timestamped backup of the current log file, truncates the original, and cleans up old backups.
 func (l *RollingFile) rotate() error {
-	// Close the current file before renaming
-	if err := l.file.Close(); err != nil {
-		return fmt.Errorf("failed to close file before rotation: %w", err)
-	}
+	l.queueEvent(RotationStarted{Path: l.file.Name()})
+	now := l.now()
+	timestamp := now.Format(backupTimeLayout)
 
-	i := 0
-	timestamp := time.Now().Format("20060102-150405")
-	backupPath := fmt.Sprintf("%s.%s.%d", l.file.Name(), timestamp, i)
+	// Reserve the backup name before touching the file, so a failure leaves it open.
+	var backupPath string
+	var err error
+	if l.persistentSequence {
+		backupPath, err = l.sequencedBackupPath(timestamp)
+	} else {
+		backupPath, err = l.uniqueBackupPath(now)
+	}
+	if err != nil {
+		return err
+	}
 
-	// Find a unique backup filename
-	_, err := os.Stat(backupPath)
-	for err == nil {
-		i++
-		backupPath = fmt.Sprintf("%s.%s.%d", l.file.Name(), timestamp, i)
-		_, err = os.Stat(backupPath)
+	// Close the current file before renaming
+	name := l.file.Name()
+	if err := l.closeFile(); err != nil {
+		l.fs.Remove(backupPath)
+		l.reopen(name)
+		return fmt.Errorf("failed to close file before rotation: %w", err)
 	}
 
-	// Rename the current file to the backup name
-	if err := os.Rename(l.file.Name(), backupPath); err != nil {
+	// Move the current file onto the reserved backup name
+	if err := l.moveBackup(name, backupPath); err != nil {
+		l.fs.Remove(backupPath)
+		l.reopen(name)
 		return fmt.Errorf("failed to rename file for rotation: %w", err)
 	}
+	l.protectBackup(backupPath)
+	l.backupBytes.Add(l.size)
+	l.size = 0
 
 	// Create a new file with the original name and same mode
-	newFile, err := os.OpenFile(l.file.Name(), os.O_CREATE|os.O_RDWR|os.O_APPEND, l.mode)
+	newFile, _, err := l.openLogFile(name, false)
 	if err != nil {
+		// The next write opens the file again.
+		l.file = nil
 		return fmt.Errorf("failed to create new log file after rotation: %w", err)
 	}
 	l.file = newFile
-	l.size = 0
+	l.setupFile()
+	l.nearMaxSizeNotified = false
+	contentStart, contentEnd := l.contentStart, l.contentEnd
+	l.contentStart, l.contentEnd = time.Time{}, time.Time{}
+	l.lastRotation.Store(now.UnixNano())
+	l.scheduleRotation(now)
+	l.rotations.Add(1)
+	l.queueEvent(RotationCompleted{BackupPath: backupPath})
 	l.cleanupWaitGroup.Add(1)
-	go l.cleanupBackups()
+	if l.postRotateCommand != nil || l.forward != nil || l.streamFunc != nil || l.metadata {
+		l.postRotatePending.Store(backupPath, struct{}{})
+		go l.postRotate(backupPath, l.file.Name(), contentStart, contentEnd)
+		return nil
+	}
+	if l.compressOnRotate() {
+		l.enqueueCompression(backupPath)
+	}
+	go l.cleanupBackups(l.file.Name())
 	return nil
 }
 
-// cleanupBackups deletes oldest backup files to enforce the maxBackups and maxAge limits.
-func (l *RollingFile) cleanupBackups() {
+// postRotate writes the metadata sidecar, runs the post-rotate command and the stream function for
+// backupPath and afterwards schedules the backup for compression and cleans up the backups of name,
+// so they all see the backup under the path they were given. start and end are the times of the
+// first and last write to the backup.
+func (l *RollingFile) postRotate(backupPath, name string, start, end time.Time) {
+	// Hand over to cleanup, which marks the work as done.
+	defer l.cleanupBackups(name)
+	defer l.postRotatePending.Delete(backupPath)
+	if l.metadata {
+		if err := l.writeMetadata(backupPath, start, end); err != nil {
+			l.errorHandler(fmt.Errorf("failed to write metadata for backup file %q: %w", backupPath, err))
+		}
+	}
+	if l.postRotateCommand != nil {
+		if err := l.runPostRotateCommand(backupPath); err != nil {
+			l.errorHandler(fmt.Errorf("post-rotate command failed for %q: %w", backupPath, err))
+		}
+	}
+	if l.forward != nil {
+		if err := l.forwardBackup(backupPath); err != nil {
+			l.errorHandler(fmt.Errorf("failed to forward backup file %q: %w", backupPath, err))
+		}
+	}
+	if l.streamFunc != nil {
+		discarded, err := l.streamBackup(backupPath)
+		if err != nil {
+			l.errorHandler(fmt.Errorf("failed to stream backup file %q: %w", backupPath, err))
+		}
+		if discarded {
+			return
+		}
+	}
+	if l.compressOnRotate() {
+		l.enqueueCompression(backupPath)
+	}
+}
+
+// cleanupBackups enforces the retention limits on the backups of name and the shared disk budget, if any.
+func (l *RollingFile) cleanupBackups(name string) {
 	defer l.cleanupWaitGroup.Done()
+	l.enforceRetention(name)
+	if l.compressionQueue != nil {
+		l.retrySkippedCompression(false)
+	}
+	// The disk budget locks the cleanup of every RollingFile sharing it, so it runs after this cleanup.
+	if l.diskBudget != nil {
+		l.diskBudget.enforce()
+	}
+}
+
+// enforceRetention deletes oldest backup files to enforce the maxBackups, maxAge and maxDays limits.
+func (l *RollingFile) enforceRetention(name string) {
 	l.cleanupMutex.Lock()
 	defer l.cleanupMutex.Unlock()
-	matches, err := filepath.Glob(l.file.Name() + ".*")
-	if err != nil {
-		l.errorHandler(fmt.Errorf("failed to list backup files: %w", err))
-		return
-	}
+	ctx, cancel := l.cleanupContext()
+	defer cancel()
 
 	var backups []string
-	for _, file := range matches {
-		if strings.HasPrefix(file, l.file.Name()+".") && len(file) > len(l.file.Name())+1 {
-			backups = append(backups, file)
-		}
+	err := bounded(ctx, func() (err error) {
+		backups, err = l.listBackups(name)
+		return err
+	})
+	if ctx.Err() != nil {
+		l.cleanupInterrupted(ctx)
+		return
+	}
+	if err != nil {
+		l.cleanupError(fmt.Errorf("failed to list backup files: %w", err))
+		return
 	}
 
-	sort.Strings(backups)
+	var retained []string
 	for i, file := range backups {
 		expired, err := l.isOlderThanFilename(file)
 		if err != nil {
-			l.errorHandler(fmt.Errorf("failed to check backup file age: %w", err))
+			l.cleanupError(fmt.Errorf("failed to check backup file age: %w", err))
+			retained = append(retained, file)
 			continue
 		}
+		// Never let age-based expiry delete the newest minBackups backups.
+		if expired && len(backups)-i <= l.minBackups {
+			expired = false
+		}
 		if (len(backups)-i > l.maxBackups && l.maxBackups > 0) || expired {
-			err = os.Remove(file)
+			err = bounded(ctx, func() error { return l.removeBackup(file) })
+			if ctx.Err() != nil {
+				l.cleanupInterrupted(ctx)
+				return
+			}
 			if err != nil {
-				l.errorHandler(fmt.Errorf("failed to remove backup file %q: %w", file, err))
+				l.cleanupError(fmt.Errorf("failed to remove backup file %q: %w", file, err))
+				retained = append(retained, file)
 			}
+			continue
 		}
+		retained = append(retained, file)
+	}
+
+	if l.maxUncompressedBackups > 0 || l.maxCompressedBackups > 0 {
+		retained = l.enforceCompressionCounts(ctx, retained)
 	}
+
+	if l.maxTotalBytes > 0 && ctx.Err() == nil {
+		// Leave room for the active file to grow to its maximum size.
+		l.enforceTotalBytes(ctx, retained, l.maxSize)
+	}
+	if ctx.Err() != nil {
+		l.cleanupInterrupted(ctx)
+		return
+	}
+	l.cleanupIncomplete.Store(false)
+}
+
+// enforceCompressionCounts applies the separate limits for uncompressed and compressed backups.
+// Uncompressed backups exceeding their limit are compressed if compression is enabled and deleted
+// otherwise. They count as compressed already, so the compressed limit holds once they are done.
+// It returns the backups still retained.
+func (l *RollingFile) enforceCompressionCounts(ctx context.Context, backups []string) []string {
+	var uncompressed, compressed []string
+	for _, file := range backups {
+		if strings.HasSuffix(file, l.compressionExt()) {
+			compressed = append(compressed, file)
+		} else {
+			uncompressed = append(uncompressed, file)
+		}
+	}
+
+	var retained []string
+	remove := func(file string) {
+		if err := bounded(ctx, func() error { return l.removeBackup(file) }); err != nil {
+			if ctx.Err() != nil {
+				// Out of budget, the caller reports the interrupted cleanup.
+				retained = append(retained, file)
+				return
+			}
+			l.cleanupError(fmt.Errorf("failed to remove backup file %q: %w", file, err))
+			retained = append(retained, file)
+		}
+	}
+
+	toCompress := map[string]bool{}
+	for i, file := range uncompressed {
+		if l.maxUncompressedBackups <= 0 || len(uncompressed)-i <= l.maxUncompressedBackups {
+			retained = append(retained, file)
+		} else if l.compress {
+			toCompress[file] = true
+			compressed = append(compressed, file)
+		} else {
+			remove(file)
+		}
+	}
+
+	sort.Strings(compressed)
+	for i, file := range compressed {
+		if l.maxCompressedBackups > 0 && len(compressed)-i > l.maxCompressedBackups {
+			remove(file)
+			continue
+		}
+		// Backups still in postRotate are compressed once it is done with them.
+		if _, pending := l.postRotatePending.Load(file); toCompress[file] && !pending {
+			l.enqueueCompression(file)
+		}
+		retained = append(retained, file)
+	}
+	sort.Strings(retained)
+	return retained
+}
+
+// removeBackup deletes a backup file together with its metadata sidecar.
+func (l *RollingFile) removeBackup(file string) error {
+	l.unprotectBackup(file)
+	if err := l.fs.Remove(file); err != nil {
+		return err
+	}
+	if l.metadata {
+		l.fs.Remove(l.metadataPath(file))
+	}
+	l.emit(BackupDeleted{Path: file})
+	return nil
+}
+
+// uniqueBackupPath returns and reserves a backup path for a rotation at now. The name consists of the
+// timestamp and the nanoseconds within its second, increased as needed so that names from the same second
+// are unique and sort chronologically. The name is reserved with O_EXCL, so that concurrent processes
+// never pick the same one without having to probe the directory.
+func (l *RollingFile) uniqueBackupPath(now time.Time) (string, error) {
+	timestamp := now.Format(backupTimeLayout)
+	counter := now.Nanosecond()
+	if timestamp == l.lastBackupTimestamp && counter <= l.lastBackupCounter {
+		counter = l.lastBackupCounter + 1
+	}
+	for {
+		backupPath := fmt.Sprintf("%s.%s.%09d", l.backupPrefix(l.file.Name()), timestamp, counter)
+		err := l.reserveBackup(backupPath, l.mode)
+		if err == nil {
+			l.lastBackupTimestamp, l.lastBackupCounter = timestamp, counter
+			return backupPath, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("failed to reserve backup file name: %w", err)
+		}
+		counter++
+	}
+}
+
+// reserveBackup creates an empty placeholder for a backup, failing if the name is taken.
+// The rotated file is renamed onto it.
+func (l *RollingFile) reserveBackup(path string, mode os.FileMode) error {
+	file, err := l.fs.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// backupExists reports whether a backup named path exists, compressed or not.
+func (l *RollingFile) backupExists(path string) bool {
+	for _, name := range []string{path, path + l.compressionExt(), path + l.compressionExt() + tmpSuffix} {
+		if _, err := l.fs.Lstat(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// listBackups returns the backup files of the file with the given name, oldest first.
+// Sequenced backups ("<name>.<sequence>.<timestamp>") sort by sequence after all
+// backups without a sequence, which predate WithPersistentSequence.
+func (l *RollingFile) listBackups(name string) ([]string, error) {
+	name = l.backupPrefix(name)
+	matches, err := l.fs.Glob(name + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, file := range matches {
+		if strings.HasSuffix(file, tmpSuffix) || strings.HasSuffix(file, metadataSuffix) || (l.trigger && file == l.triggerPath) {
+			continue
+		}
+		if strings.HasPrefix(file, name+".") && len(file) > len(name)+1 {
+			backups = append(backups, file)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		si, _ := backupSequence(name, backups[i])
+		sj, _ := backupSequence(name, backups[j])
+		if si != sj {
+			return si < sj
+		}
+		return backups[i] < backups[j]
+	})
+	return backups, nil
 }
 
 // isOlderThanFilename returns true if the embedded timestamp in fname
-// (in the form ".log.YYYYMMDD-HHMMSS.") is before cutoff.
+// (in the form ".YYYYMMDD-HHMMSS.N" or ".SEQ.YYYYMMDD-HHMMSS") is before the maxAge or maxDays cutoff.
 func (l *RollingFile) isOlderThanFilename(fname string) (bool, error) {
-	if l.maxAge <= 0 {
+	if l.maxAge <= 0 && l.maxDays <= 0 {
 		return false, nil
 	}
-	re := regexp.MustCompile(`\.log\.(\d{8}-\d{6})\.`)
-	matches := re.FindStringSubmatch(fname)
+	matches := backupTimestampRegexp.FindStringSubmatch(fname)
 	if len(matches) < 2 {
 		return false, fmt.Errorf("no timestamp found in %q", fname)
 	}
 
-	ts, err := time.Parse("20060102-150405", matches[1])
+	// Backup timestamps are written in local time.
+	ts, err := time.ParseInLocation(backupTimeLayout, matches[1], time.Local)
 	if err != nil {
 		return false, fmt.Errorf("cannot parse timestamp %q: %w", matches[1], err)
 	}
-	cutoff := time.Now().Add(-l.maxAge)
-
-	return ts.Before(cutoff), nil
+	now := l.now()
+	if l.maxAge > 0 && ts.Before(now.Add(-l.maxAge)) {
+		return true, nil
+	}
+	if l.maxDays > 0 {
+		// Midnight of the oldest retained day. time.Date normalizes the day
+		// and accounts for DST transitions, unlike subtracting 24h multiples.
+		y, m, d := now.Date()
+		cutoff := time.Date(y, m, d-l.maxDays+1, 0, 0, 0, 0, now.Location())
+		if ts.Before(cutoff) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-// Close waits for pending backup cleanup to finish and
+// Close waits for pending backup cleanup and compression to finish and
 // calls the Close function on the underlying file.
 func (l *RollingFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopIdle()
+	// Pending cleanups still enforce the disk budget on this RollingFile.
 	l.cleanupWaitGroup.Wait()
-	return l.file.Close()
+	if l.diskBudget != nil {
+		l.diskBudget.remove(l)
+	}
+	l.stopCompression()
+	if l.quarantineFile != nil {
+		l.quarantineFile.Close()
+	}
+	defer l.releaseLock()
+	if len(l.pending) > 0 {
+		if l.file == nil {
+			l.openActive()
+		}
+		if l.file != nil {
+			l.flushPending()
+		}
+		if len(l.pending) > 0 {
+			err := fmt.Errorf("dropped %d bytes of queued writes", len(l.pending))
+			l.pending = nil
+			if l.file != nil {
+				l.closeFile()
+			}
+			return err
+		}
+	}
+	if l.file == nil {
+		return nil
+	}
+	return l.closeFile()
 }
 
 // Sync calls the Sync function on the underlying file.
 func (l *RollingFile) Sync() error {
-	return l.file.Sync()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.syncFile()
 }
 
 // Name returns the name of the underlying file.
 func (l *RollingFile) Name() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.activeName()
+}
+
+// activeName returns the name of the active file, which is the configured path until it is opened.
+func (l *RollingFile) activeName() string {
+	if l.file == nil {
+		return l.path
+	}
 	return l.file.Name()
 }
+
+// Stats returns a snapshot of the RollingFile's internal state.
+func (l *RollingFile) Stats() Stats {
+	return Stats{
+		CompressionQueueDepth: int(l.compressionQueued.Load()),
+		BackupBytes:           l.backupBytes.Load(),
+		CleanupIncomplete:     l.cleanupIncomplete.Load(),
+		Rotations:             l.rotations.Load(),
+	}
+}
+
+// now returns the current time from the clock set with WithClock, or the system clock.
+func (l *RollingFile) now() time.Time {
+	if l.clock == nil {
+		return time.Now()
+	}
+	return l.clock()
+}
@@ -7,8 +7,10 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestWrite verifies that the logger writes messages with the correct prefix
@@ -164,3 +166,211 @@ func TestRotationLinesRetained(t *testing.T) {
 
 	assert.Equal(t, lineCount, total, "expected %d lines in all files, got %d", lineCount, total)
 }
+
+// TestMaxDaysUsesCalendarDays verifies that backups are expired at local midnight boundaries
+// rather than after a rolling duration.
+func TestMaxDaysUsesCalendarDays(t *testing.T) {
+	logger := &RollingFile{maxDays: 2}
+	now := time.Now()
+	y, m, d := now.Date()
+	name := func(ts time.Time) string {
+		return "app.log." + ts.Format(backupTimeLayout) + ".0"
+	}
+
+	startOfYesterday := time.Date(y, m, d-1, 0, 0, 0, 0, time.Local)
+	endOfDayBefore := startOfYesterday.Add(-time.Second)
+
+	expired, err := logger.isOlderThanFilename(name(startOfYesterday))
+	assert.NoError(t, err)
+	assert.False(t, expired, "backup from yesterday should be retained")
+
+	expired, err = logger.isOlderThanFilename(name(endOfDayBefore))
+	assert.NoError(t, err)
+	assert.True(t, expired, "backup from two days ago should be expired")
+
+	expired, err = logger.isOlderThanFilename(name(now) + ".gz")
+	assert.NoError(t, err)
+	assert.False(t, expired, "compressed backup from today should be retained")
+
+	_, err = logger.isOlderThanFilename("app.log.notatimestamp")
+	assert.Error(t, err)
+}
+
+// TestMinBackupsSurviveMaxAge ensures that age-based expiry never deletes below the minimum number of backups.
+func TestMinBackupsSurviveMaxAge(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "min.log")
+
+	old := time.Now().AddDate(0, 0, -7).Format(backupTimeLayout)
+	for i := 0; i < 3; i++ {
+		err := os.WriteFile(fmt.Sprintf("%s.%s.%d", logPath, old, i), []byte("old\n"), 0644)
+		assert.NoError(t, err)
+	}
+
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMaxAge(time.Hour),
+		WithMinBackups(2),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("m", 80) + "\n"
+	for i := 0; i < 2; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, fmt.Sprintf("%s.%s.%d", logPath, old, 2), files[0])
+}
+
+// TestTeeReceivesWrites verifies that writes are copied to the tee and that tee failures
+// do not fail the write to the file.
+func TestTeeReceivesWrites(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "tee.log")
+	var tee bytes.Buffer
+	var errs []error
+	logger, err := New(logPath,
+		WithTee(&tee),
+		WithTee(failingWriter{}),
+		WithLinePrefix(func() []byte { return []byte("- ") }),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }),
+	)
+	assert.NoError(t, err)
+
+	_, err = logger.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "- hello\n", string(contents))
+	assert.Equal(t, "- hello\n", tee.String())
+	assert.Len(t, errs, 1)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, fmt.Errorf("sink unavailable")
+}
+
+// TestLazyOpenCreatesFileOnFirstWrite verifies that WithLazyOpen leaves no file behind
+// unless something is written.
+func TestLazyOpenCreatesFileOnFirstWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	unused := filepath.Join(tmpDir, "unused.log")
+	logger, err := New(unused, WithLazyOpen())
+	assert.NoError(t, err)
+	assert.Equal(t, unused, logger.Name())
+	assert.NoError(t, logger.Healthy())
+	assert.NoError(t, logger.Sync())
+	r, err := logger.ReadCurrent()
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.NoError(t, logger.Close())
+	assert.NoFileExists(t, unused)
+
+	used := filepath.Join(tmpDir, "used.log")
+	logger, err = New(used, WithLazyOpen(), WithMaxBytes(10))
+	assert.NoError(t, err)
+	assert.NoFileExists(t, used)
+	_, err = logger.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	contents, err := os.ReadFile(used)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(contents))
+}
+
+// TestOpenFlags verifies that custom open flags are used in New and after rotation.
+func TestOpenFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "flags.log")
+	assert.NoError(t, os.WriteFile(logPath, []byte("partial"), 0644))
+
+	_, err := New(logPath, WithOpenFlags(os.O_WRONLY|os.O_EXCL))
+	assert.ErrorIs(t, err, os.ErrExist)
+
+	logger, err := New(logPath, WithOpenFlags(os.O_WRONLY|os.O_SYNC), WithRecoveryMarker(), WithMaxBytes(60))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("after\n"))
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte(strings.Repeat("r", 30) + "\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("r", 30)+"\n", string(contents))
+	backups, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		contents, err = os.ReadFile(backups[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "partial\n"+RecoveryMarker+"after\n", string(contents))
+	}
+}
+
+// TestOpenFlagsOnReopen ensures that os.O_EXCL and os.O_TRUNC only apply to the first open,
+// so that reopening a released file continues it.
+func TestOpenFlagsOnReopen(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, flag := range []int{os.O_EXCL, os.O_TRUNC} {
+		logPath := filepath.Join(tmpDir, fmt.Sprintf("reopen-%d.log", flag))
+		logger, err := New(logPath, WithOpenFlags(os.O_WRONLY|flag))
+		assert.NoError(t, err)
+		_, err = logger.Write([]byte("before\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, logger.Release())
+		_, err = logger.Write([]byte("after\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, logger.Close())
+
+		contents, err := os.ReadFile(logPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "before\nafter\n", string(contents))
+	}
+}
+
+// TestUniqueBackupNames verifies that backup names from the same second are unique, sort in
+// rotation order and skip names reserved by other processes.
+func TestUniqueBackupNames(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "unique.log")
+	logger, err := New(logPath, WithMaxBytes(5))
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		_, err := fmt.Fprintf(logger, "%03d\n", i)
+		assert.NoError(t, err)
+	}
+
+	now := time.Now()
+	taken := fmt.Sprintf("%s.%s.%09d", logPath, now.Format(backupTimeLayout), now.Nanosecond()+1000)
+	assert.NoError(t, os.WriteFile(taken, nil, 0644))
+	logger.mu.Lock()
+	logger.lastBackupTimestamp, logger.lastBackupCounter = now.Format(backupTimeLayout), now.Nanosecond()+999
+	reserved, err := logger.uniqueBackupPath(now)
+	logger.mu.Unlock()
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s.%s.%09d", logPath, now.Format(backupTimeLayout), now.Nanosecond()+1001), reserved)
+	assert.NoError(t, os.Remove(reserved))
+	assert.NoError(t, os.Remove(taken))
+	assert.NoError(t, logger.Close())
+
+	backups, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 19)
+	for i, backup := range backups {
+		contents, err := os.ReadFile(backup)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%03d\n", i), string(contents))
+	}
+}
@@ -0,0 +1,385 @@
+package rollingfiletest
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/romosch/rollingfile"
+)
+
+// MemFS is an in-memory filesystem for WithFS. Names are cleaned with path/filepath; the current directory
+// and the root directory always exist, other directories are created with MkdirAll. Permissions are recorded
+// but not enforced. Open files keep their content across Rename and Remove, like on Unix.
+// It is safe for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string]*memNode
+	dirs  map[string]bool
+}
+
+// memNode is the content and metadata of a file, shared by its open handles.
+type memNode struct {
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+var _ rollingfile.FS = (*MemFS)(nil)
+
+// NewMemFS returns an empty MemFS.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		files: map[string]*memNode{},
+		dirs:  map[string]bool{".": true, string(filepath.Separator): true},
+	}
+}
+
+// ReadFile returns the content of the file name.
+func (fs *MemFS) ReadFile(name string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	node, ok := fs.files[filepath.Clean(name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+// WriteFile creates or replaces the file name with data, e.g. to set up existing backups.
+func (fs *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	file, err := fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	file.Write(data)
+	return file.Close()
+}
+
+// OpenFile opens the file name with the os.OpenFile flags os.O_RDONLY, os.O_WRONLY, os.O_RDWR, os.O_APPEND,
+// os.O_CREATE, os.O_EXCL and os.O_TRUNC; other flags are ignored.
+func (fs *MemFS) OpenFile(name string, flag int, perm os.FileMode) (rollingfile.File, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	clean := filepath.Clean(name)
+	node, ok := fs.files[clean]
+	switch {
+	case fs.dirs[clean]:
+		return nil, &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case !ok:
+		if !fs.dirs[filepath.Dir(clean)] {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		node = &memNode{mode: perm.Perm(), modTime: time.Now()}
+		fs.files[clean] = node
+	}
+	access := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	file := &memFile{
+		fs:       fs,
+		node:     node,
+		name:     name,
+		readable: access != os.O_WRONLY,
+		writable: access != os.O_RDONLY,
+		append:   flag&os.O_APPEND != 0,
+	}
+	if flag&os.O_TRUNC != 0 && file.writable {
+		node.data = node.data[:0]
+		node.modTime = time.Now()
+	}
+	return file, nil
+}
+
+// Stat returns the file info of the file or directory name.
+func (fs *MemFS) Stat(name string) (os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	clean := filepath.Clean(name)
+	if node, ok := fs.files[clean]; ok {
+		return node.info(clean), nil
+	}
+	if fs.dirs[clean] {
+		return memFileInfo{name: filepath.Base(clean), mode: os.ModeDir | 0755}, nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+// Lstat is the same as Stat, as MemFS has no symbolic links.
+func (fs *MemFS) Lstat(name string) (os.FileInfo, error) {
+	return fs.Stat(name)
+}
+
+// Rename moves the file oldpath to newpath, replacing newpath if it exists.
+func (fs *MemFS) Rename(oldpath, newpath string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	oldClean, newClean := filepath.Clean(oldpath), filepath.Clean(newpath)
+	node, ok := fs.files[oldClean]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if !fs.dirs[filepath.Dir(newClean)] {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if fs.dirs[newClean] {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrExist}
+	}
+	delete(fs.files, oldClean)
+	fs.files[newClean] = node
+	return nil
+}
+
+// Remove removes the file or empty directory name.
+func (fs *MemFS) Remove(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	clean := filepath.Clean(name)
+	if _, ok := fs.files[clean]; ok {
+		delete(fs.files, clean)
+		return nil
+	}
+	if !fs.dirs[clean] {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	prefix := clean + string(filepath.Separator)
+	for other := range fs.files {
+		if strings.HasPrefix(other, prefix) {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	for other := range fs.dirs {
+		if strings.HasPrefix(other, prefix) {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	delete(fs.dirs, clean)
+	return nil
+}
+
+// Chmod sets the permission bits of the file name.
+func (fs *MemFS) Chmod(name string, mode os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	node, ok := fs.files[filepath.Clean(name)]
+	if !ok {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	node.mode = mode.Perm()
+	return nil
+}
+
+// MkdirAll creates the directory path and its missing parents.
+func (fs *MemFS) MkdirAll(path string, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for dir := filepath.Clean(path); !fs.dirs[dir]; dir = filepath.Dir(dir) {
+		if _, ok := fs.files[dir]; ok {
+			return &os.PathError{Op: "mkdir", Path: path, Err: errors.New("not a directory")}
+		}
+		fs.dirs[dir] = true
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return nil
+}
+
+// Glob returns the sorted names of the files matching pattern, see filepath.Match.
+func (fs *MemFS) Glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	pattern = filepath.Clean(pattern)
+	var matches []string
+	for name := range fs.files {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (n *memNode) info(name string) memFileInfo {
+	return memFileInfo{name: filepath.Base(name), size: int64(len(n.data)), mode: n.mode, modTime: n.modTime, node: n}
+}
+
+// memFile is an open handle of a MemFS file.
+type memFile struct {
+	fs       *MemFS
+	node     *memNode
+	name     string
+	offset   int64
+	readable bool
+	writable bool
+	append   bool
+	closed   bool
+}
+
+func (f *memFile) check(op string, write bool) error {
+	switch {
+	case f.closed:
+		return &os.PathError{Op: op, Path: f.name, Err: os.ErrClosed}
+	case write && !f.writable, !write && !f.readable:
+		return &os.PathError{Op: op, Path: f.name, Err: os.ErrPermission}
+	}
+	return nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	n, err := f.readAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	n, err := f.readAt(p, off)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *memFile) readAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(f.node.data)) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	return copy(p, f.node.data[off:]), nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if f.append {
+		f.offset = int64(len(f.node.data))
+	}
+	f.writeAt(p, f.offset)
+	f.offset += int64(len(p))
+	return len(p), nil
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if f.append {
+		return 0, errors.New("rollingfiletest: invalid use of WriteAt on file opened with O_APPEND")
+	}
+	f.writeAt(p, off)
+	return len(p), nil
+}
+
+func (f *memFile) writeAt(p []byte, off int64) {
+	if end := off + int64(len(p)); end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	copy(f.node.data[off:], p)
+	f.node.modTime = time.Now()
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return &os.PathError{Op: "close", Path: f.name, Err: os.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return nil, &os.PathError{Op: "stat", Path: f.name, Err: os.ErrClosed}
+	}
+	return f.node.info(f.name), nil
+}
+
+func (f *memFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return &os.PathError{Op: "sync", Path: f.name, Err: os.ErrClosed}
+	}
+	return nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("truncate", true); err != nil {
+		return err
+	}
+	if size < int64(len(f.node.data)) {
+		f.node.data = f.node.data[:size]
+	} else {
+		f.writeAt(nil, size)
+	}
+	f.node.modTime = time.Now()
+	return nil
+}
+
+func (f *memFile) Chmod(mode os.FileMode) error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return &os.PathError{Op: "chmod", Path: f.name, Err: os.ErrClosed}
+	}
+	f.node.mode = mode.Perm()
+	return nil
+}
+
+// memFileInfo describes a MemFS file or directory. Sys returns the shared content of a file, which
+// identifies it across renames.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	node    *memNode
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() os.FileMode  { return i.mode }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memFileInfo) Sys() any {
+	if i.node == nil {
+		return nil
+	}
+	return i.node
+}
@@ -0,0 +1,94 @@
+package rollingfiletest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/romosch/rollingfile"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemFSRotation verifies that rotation, compression, retention and sidecar files work entirely
+// in memory, without touching the disk.
+func TestMemFSRotation(t *testing.T) {
+	fs := NewMemFS()
+	rf := New(t, "app.log", nil,
+		rollingfile.WithFS(fs),
+		rollingfile.WithMaxBytes(20),
+		rollingfile.WithMaxBackups(2),
+		rollingfile.WithCompression(),
+		rollingfile.WithMetadata(),
+		rollingfile.WithBackupDir("old"),
+		rollingfile.WithPersistentSequence(),
+	)
+
+	var lines []string
+	for i := 0; i < 5; i++ {
+		line := strings.Repeat(string(rune('a'+i)), 15) + "\n"
+		lines = append(lines, line)
+		_, err := rf.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	ExpectRotations(t, rf, 4)
+	assert.NoError(t, rf.Close())
+	assert.Equal(t, strings.Join(lines[2:], ""), string(ReadAllRetained(t, rf)))
+
+	backups, err := fs.Glob("old/app.log.*.gz")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	sidecars, err := fs.Glob("old/app.log.*" + ".meta.json")
+	assert.NoError(t, err)
+	assert.Len(t, sidecars, 2)
+	active, err := fs.ReadFile("app.log")
+	assert.NoError(t, err)
+	assert.Equal(t, lines[4], string(active))
+
+	_, err = os.Stat("app.log")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat("old")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// TestMemFSRejectsOSOptions verifies that options relying on the operating system's filesystem are rejected.
+func TestMemFSRejectsOSOptions(t *testing.T) {
+	_, err := rollingfile.New("app.log", rollingfile.WithFS(NewMemFS()), rollingfile.WithExclusive())
+	assert.Error(t, err)
+}
+
+// TestMemFSFiles verifies the file semantics RollingFile relies on.
+func TestMemFSFiles(t *testing.T) {
+	fs := NewMemFS()
+	_, err := fs.OpenFile("missing/app.log", os.O_CREATE|os.O_WRONLY, 0644)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	f, err := fs.OpenFile("app.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	_, err = fs.OpenFile("app.log", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	assert.ErrorIs(t, err, os.ErrExist)
+
+	before, err := f.Stat()
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Rename("app.log", "app.log.1"))
+	_, err = f.Write([]byte("world\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	after, err := fs.Stat("app.log.1")
+	assert.NoError(t, err)
+	assert.Equal(t, before.Sys(), after.Sys(), "a renamed file stays the same file")
+	assert.Equal(t, os.FileMode(0640), after.Mode())
+
+	data, err := fs.ReadFile("app.log.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\nworld\n", string(data))
+	_, err = fs.Stat("app.log")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.NoError(t, fs.MkdirAll("a/b", 0755))
+	info, err := fs.Stat("a")
+	assert.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Error(t, fs.Remove("a"))
+}
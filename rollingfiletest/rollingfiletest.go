@@ -0,0 +1,90 @@
+// Package rollingfiletest provides helpers for deterministic tests of code that logs through a RollingFile,
+// without sleeping for rotation intervals or touching the disk: a fake Clock, an in-memory filesystem (MemFS)
+// and assertions on rotations and retained content.
+package rollingfiletest
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/romosch/rollingfile"
+)
+
+// Clock is a fake clock for WithClock that only moves when told to. It is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the clock to t, which may also move it backwards, like an NTP step.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// New creates a RollingFile at name on a new MemFS, using clock if it is not nil. To inspect the files,
+// pass rollingfile.WithFS with a MemFS of the test's own in options. The RollingFile is closed when
+// the test ends. The test fails immediately if the RollingFile cannot be created.
+func New(t testing.TB, name string, clock *Clock, options ...rollingfile.Option) *rollingfile.RollingFile {
+	t.Helper()
+	options = append([]rollingfile.Option{rollingfile.WithFS(NewMemFS())}, options...)
+	if clock != nil {
+		options = append(options, rollingfile.WithClock(clock.Now))
+	}
+	rf, err := rollingfile.New(name, options...)
+	if err != nil {
+		t.Fatalf("rollingfiletest: %v", err)
+	}
+	t.Cleanup(func() { rf.Close() })
+	return rf
+}
+
+// ExpectRotations reports a test error unless rf has rotated exactly n times.
+func ExpectRotations(t testing.TB, rf *rollingfile.RollingFile, n int) {
+	t.Helper()
+	if got := rf.Stats().Rotations; got != int64(n) {
+		t.Errorf("rollingfiletest: expected %d rotations of %s, got %d", n, rf.Name(), got)
+	}
+}
+
+// ReadAllRetained returns the content of all retained backups, oldest first, followed by the active file,
+// decompressing compressed backups. It may also be called after rf was closed, when background compression
+// and cleanup have finished. The test fails immediately if the files cannot be read.
+func ReadAllRetained(t testing.TB, rf *rollingfile.RollingFile) []byte {
+	t.Helper()
+	if err := rf.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("rollingfiletest: %v", err)
+	}
+	var data []byte
+	err := rf.Search(rollingfile.SearchQuery{}, func(m rollingfile.SearchMatch) error {
+		data = append(data, m.Line...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("rollingfiletest: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,36 @@
+package rollingfiletest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romosch/rollingfile"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTimeBasedRotationWithFakeClock verifies that the fake clock drives time-based rotation
+// and that the helpers observe the rotations and the retained content.
+func TestTimeBasedRotationWithFakeClock(t *testing.T) {
+	clock := NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local))
+	rf := New(t, "app.log", clock, rollingfile.WithRotationInterval(time.Hour))
+
+	rf.Write([]byte("first\n"))
+	clock.Advance(30 * time.Minute)
+	rf.Write([]byte("second\n"))
+	ExpectRotations(t, rf, 0)
+
+	clock.Advance(time.Hour)
+	rf.Write([]byte("third\n"))
+	ExpectRotations(t, rf, 1)
+	assert.Equal(t, clock.Now().Add(30*time.Minute), rf.NextRotation())
+	assert.Equal(t, "first\nsecond\nthird\n", string(ReadAllRetained(t, rf)))
+}
+
+// TestClockSet verifies that the fake clock can be moved backwards.
+func TestClockSet(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	clock.Advance(time.Hour)
+	clock.Set(start.Add(-time.Minute))
+	assert.Equal(t, start.Add(-time.Minute), clock.Now())
+}
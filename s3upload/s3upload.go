@@ -0,0 +1,312 @@
+// Package s3upload uploads rotated backups of a RollingFile to S3-compatible object storage. It plugs into
+// rollingfile.WithRotationStream, so backups are streamed to the bucket right after rotation and can be
+// deleted locally once uploaded. Large backups are sent with multipart uploads, and failed requests
+// are retried with exponential backoff.
+package s3upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/romosch/rollingfile"
+)
+
+const (
+	// MinPartSize is the smallest part size S3 accepts for all but the last part of a multipart upload.
+	MinPartSize = 5 << 20
+	// DefaultPartSize is the part size used when Config.PartSize is zero.
+	DefaultPartSize = 16 << 20
+	// DefaultKeyTemplate is the key template used when Config.KeyTemplate is empty.
+	DefaultKeyTemplate = "{{.Hostname}}/{{.Base}}"
+	// DefaultTimeout is the request timeout used when Config.Timeout is zero.
+	DefaultTimeout = 5 * time.Minute
+
+	defaultMaxRetries = 5
+	defaultBackoff    = 500 * time.Millisecond
+	maxBackoff        = 30 * time.Second
+)
+
+// Config configures an Uploader.
+type Config struct {
+	// Endpoint is the URL of an S3-compatible service, e.g. "http://minio:9000", addressed with
+	// path-style requests. If empty, AWS S3 in Region is addressed with virtual-hosted-style requests.
+	Endpoint string
+	// Region is the region of the bucket. Defaults to "us-east-1".
+	Region string
+	// Bucket is the name of the bucket to upload to.
+	Bucket string
+	// AccessKeyID, SecretAccessKey and the optional SessionToken are the credentials to sign requests with.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// KeyTemplate is a text/template for the object key of a backup, executed with KeyData.
+	// Defaults to DefaultKeyTemplate.
+	KeyTemplate string
+	// PartSize is the size of the parts of multipart uploads, at least MinPartSize. Backups smaller than
+	// a part are uploaded with a single request. Defaults to DefaultPartSize.
+	PartSize int
+	// MaxRetries is the number of times a failed request is retried, with exponential backoff starting
+	// at Backoff. Default to 5 retries and 500ms; a negative MaxRetries disables retries.
+	MaxRetries int
+	Backoff    time.Duration
+	// Timeout bounds every request, including reading its response, so that a stalled connection is
+	// retried instead of blocking the upload forever. Defaults to DefaultTimeout; a negative Timeout
+	// disables it, leaving only the timeouts of Client.
+	Timeout time.Duration
+	// Client is the HTTP client used for requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// KeyData is the data the key template is executed with.
+type KeyData struct {
+	// Path is the path of the backup, Base its base name and Dir its directory.
+	Path, Base, Dir string
+	// Hostname is the name of the host.
+	Hostname string
+	// Time is the time of the upload, in UTC.
+	Time time.Time
+}
+
+// Uploader uploads backups to an S3 bucket. It is safe for concurrent use.
+type Uploader struct {
+	cfg      Config
+	creds    credentials
+	key      *template.Template
+	base     *url.URL
+	hostname string
+	now      func() time.Time
+	sleep    func(time.Duration)
+}
+
+// New returns an Uploader for cfg.
+func New(cfg Config) (*Uploader, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3upload: bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.KeyTemplate == "" {
+		cfg.KeyTemplate = DefaultKeyTemplate
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < MinPartSize {
+		return nil, fmt.Errorf("s3upload: part size %d is below the minimum of %d bytes", cfg.PartSize, MinPartSize)
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultBackoff
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	key, err := template.New("key").Parse(cfg.KeyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("s3upload: invalid key template: %w", err)
+	}
+
+	var base *url.URL
+	if cfg.Endpoint == "" {
+		base = &url.URL{Scheme: "https", Host: cfg.Bucket + ".s3." + cfg.Region + ".amazonaws.com", Path: "/"}
+	} else {
+		if base, err = url.Parse(cfg.Endpoint); err != nil {
+			return nil, fmt.Errorf("s3upload: invalid endpoint: %w", err)
+		}
+		base.Path = strings.TrimSuffix(base.Path, "/") + "/" + cfg.Bucket + "/"
+	}
+	hostname, _ := os.Hostname()
+	return &Uploader{
+		cfg:      cfg,
+		creds:    credentials{cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken, cfg.Region},
+		key:      key,
+		base:     base,
+		hostname: hostname,
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}, nil
+}
+
+// Option returns an option uploading every rotated backup of a RollingFile with u. If deleteAfterUpload is true,
+// the local backup is deleted once it has been uploaded. Failed uploads are passed to the error handler of the
+// RollingFile and the backup is kept.
+func Option(u *Uploader, deleteAfterUpload bool) rollingfile.Option {
+	return rollingfile.WithRotationStream(u.Upload, deleteAfterUpload)
+}
+
+// Upload uploads the content of the backup at backupPath read from r under the key generated by the key template.
+func (u *Uploader) Upload(backupPath string, r io.Reader) error {
+	key, err := u.Key(backupPath)
+	if err != nil {
+		return err
+	}
+	part := make([]byte, u.cfg.PartSize)
+	n, err := io.ReadFull(r, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return u.putObject(key, part[:n])
+	}
+	if err != nil {
+		return err
+	}
+	return u.multipartUpload(key, part, r)
+}
+
+// Key returns the object key of the backup at backupPath.
+func (u *Uploader) Key(backupPath string) (string, error) {
+	var key strings.Builder
+	err := u.key.Execute(&key, KeyData{
+		Path:     backupPath,
+		Base:     filepath.Base(backupPath),
+		Dir:      filepath.Dir(backupPath),
+		Hostname: u.hostname,
+		Time:     u.now().UTC(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3upload: failed to generate key: %w", err)
+	}
+	return strings.TrimPrefix(key.String(), "/"), nil
+}
+
+func (u *Uploader) putObject(key string, data []byte) error {
+	_, err := u.do(http.MethodPut, key, nil, data)
+	return err
+}
+
+// multipartUpload uploads first and the rest of r in parts. The upload is aborted if a part fails.
+func (u *Uploader) multipartUpload(key string, first []byte, r io.Reader) error {
+	resp, err := u.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp.body, &initiated); err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("s3upload: invalid response initiating upload of %q: %s", key, resp.body)
+	}
+	uploadID := initiated.UploadID
+
+	err = u.uploadParts(key, uploadID, first, r)
+	if err != nil {
+		if _, aerr := u.do(http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil); aerr != nil {
+			err = fmt.Errorf("%w (abort failed: %v)", err, aerr)
+		}
+	}
+	return err
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+func (u *Uploader) uploadParts(key, uploadID string, part []byte, r io.Reader) error {
+	var parts []completedPart
+	for number := 1; len(part) > 0; number++ {
+		resp, err := u.do(http.MethodPut, key, url.Values{
+			"partNumber": {strconv.Itoa(number)},
+			"uploadId":   {uploadID},
+		}, part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.header.Get("ETag")})
+
+		n, err := io.ReadFull(r, part[:cap(part)])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		part = part[:n]
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := u.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return err
+	}
+	// Completing an upload can fail after the status was sent, which is reported in the body.
+	if bytes.Contains(resp.body, []byte("<Error>")) {
+		return fmt.Errorf("s3upload: failed to complete upload of %q: %s", key, resp.body)
+	}
+	return nil
+}
+
+type response struct {
+	header http.Header
+	body   []byte
+}
+
+// do sends a signed request, retrying network errors, throttling and server errors with exponential backoff.
+func (u *Uploader) do(method, key string, query url.Values, body []byte) (response, error) {
+	backoff := u.cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, retry, err := u.send(method, key, query, body)
+		if err == nil || !retry || attempt >= u.cfg.MaxRetries {
+			return resp, err
+		}
+		u.sleep(backoff)
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+func (u *Uploader) send(method, key string, query url.Values, body []byte) (response, bool, error) {
+	target := *u.base
+	target.Path += key
+	target.RawQuery = strings.ReplaceAll(query.Encode(), "uploads=", "uploads")
+	// Keys are escaped as in the signature, which url.URL would not do for all characters.
+	target.RawPath = escapePath(target.Path)
+	ctx := context.Background()
+	if u.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, u.cfg.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return response{}, false, err
+	}
+	payloadHash := emptySHA256
+	if len(body) > 0 {
+		payloadHash = hashHex(body)
+	}
+	u.creds.sign(req, payloadHash, u.now())
+
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return response{}, true, fmt.Errorf("s3upload: %s %q: %w", method, key, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, true, fmt.Errorf("s3upload: %s %q: %w", method, key, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return response{}, retry, fmt.Errorf("s3upload: %s %q: %s: %s", method, key, resp.Status, bytes.TrimSpace(data))
+	}
+	return response{header: resp.Header, body: data}, false, nil
+}
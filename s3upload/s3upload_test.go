@@ -0,0 +1,194 @@
+package s3upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/romosch/rollingfile"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal S3-compatible server keeping uploaded objects in memory.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string]map[int][]byte
+	failures int
+	requests []string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hashHex(body) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	key := r.URL.Path
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := strconv.Itoa(len(s.uploads) + 1)
+		s.uploads[id] = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		s.uploads[query.Get("uploadId")][number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		var complete struct {
+			Parts []completedPart `xml:"Part"`
+		}
+		xml.Unmarshal(body, &complete)
+		var object []byte
+		for _, part := range complete.Parts {
+			object = append(object, s.uploads[query.Get("uploadId")][part.PartNumber]...)
+		}
+		s.objects[key] = object
+		delete(s.uploads, query.Get("uploadId"))
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+	case r.Method == http.MethodPut:
+		s.objects[key] = body
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func newTestUploader(t *testing.T, server *httptest.Server, cfg Config) *Uploader {
+	cfg.Endpoint = server.URL
+	cfg.Bucket = "logs"
+	cfg.AccessKeyID = "key"
+	cfg.SecretAccessKey = "secret"
+	u, err := New(cfg)
+	assert.NoError(t, err)
+	u.sleep = func(time.Duration) {}
+	return u
+}
+
+// TestUploadSingleRequest verifies that small backups are uploaded with a single request under the templated key.
+func TestUploadSingleRequest(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	u := newTestUploader(t, server, Config{KeyTemplate: "app/{{.Time.Format \"2006-01-02\"}}/{{.Base}}"})
+	u.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	assert.NoError(t, u.Upload("/var/log/app.log.20240301-120000.0", strings.NewReader("hello\n")))
+	assert.Equal(t, map[string][]byte{"/logs/app/2024-03-01/app.log.20240301-120000.0": []byte("hello\n")}, fake.objects)
+}
+
+// TestUploadMultipart verifies that large backups are uploaded in parts and that failed requests are retried.
+func TestUploadMultipart(t *testing.T) {
+	fake := newFakeS3()
+	fake.failures = 2
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	u := newTestUploader(t, server, Config{PartSize: MinPartSize, KeyTemplate: "{{.Base}}"})
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), (2*MinPartSize+1000)/16)
+	assert.NoError(t, u.Upload("app log+1", bytes.NewReader(data)))
+	assert.Equal(t, data, fake.objects["/logs/app log+1"])
+	assert.Empty(t, fake.uploads)
+	assert.Len(t, fake.requests, 2+1+3+1)
+}
+
+// TestUploadAbortsFailedMultipart verifies that a multipart upload is aborted when a part cannot be uploaded.
+func TestUploadAbortsFailedMultipart(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	u := newTestUploader(t, server, Config{PartSize: MinPartSize, MaxRetries: -1})
+
+	err := u.Upload("app.log.1", io.MultiReader(bytes.NewReader(make([]byte, MinPartSize)), iotestErrReader{}))
+	assert.ErrorContains(t, err, "read failed")
+	assert.Empty(t, fake.uploads)
+	assert.Empty(t, fake.objects)
+}
+
+type iotestErrReader struct{}
+
+func (iotestErrReader) Read([]byte) (int, error) { return 0, fmt.Errorf("read failed") }
+
+// TestOptionDeletesAfterUpload verifies the integration with a RollingFile.
+func TestOptionDeletesAfterUpload(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	u := newTestUploader(t, server, Config{KeyTemplate: "{{.Base}}"})
+
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	logger, err := rollingfile.New(logPath, rollingfile.WithMaxBytes(10), Option(u, true))
+	assert.NoError(t, err)
+	logger.Write([]byte("first\n"))
+	logger.Write([]byte("second\n"))
+	assert.NoError(t, logger.Close())
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if assert.Len(t, fake.objects, 1) {
+		for key, object := range fake.objects {
+			assert.True(t, strings.HasPrefix(key, "/logs/app.log."))
+			assert.Equal(t, "first\n", string(object))
+		}
+	}
+	backups, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+	_, err = os.Stat(logPath)
+	assert.NoError(t, err)
+}
+
+// TestUploadTimeout verifies that a request to a stalled server fails once the timeout expires.
+func TestUploadTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	u := newTestUploader(t, server, Config{Timeout: 50 * time.Millisecond, MaxRetries: -1})
+
+	err := u.Upload("app.log.1", strings.NewReader("content"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestNewValidatesConfig verifies that invalid configurations are rejected.
+func TestNewValidatesConfig(t *testing.T) {
+	_, err := New(Config{})
+	assert.ErrorContains(t, err, "bucket")
+	_, err = New(Config{Bucket: "logs", PartSize: 1024})
+	assert.ErrorContains(t, err, "part size")
+	_, err = New(Config{Bucket: "logs", KeyTemplate: "{{"})
+	assert.ErrorContains(t, err, "key template")
+}
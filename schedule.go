@@ -0,0 +1,60 @@
+package rollingfile
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// rotationDue reports whether the rotation interval has elapsed. It advances the
+// schedule if the interval elapsed while the file was empty, so no empty backups are created.
+func (l *RollingFile) rotationDue(now time.Time) bool {
+	if l.rotationInterval <= 0 || now.UnixNano() < l.nextRotation.Load() {
+		return false
+	}
+	if l.size == 0 {
+		l.scheduleRotation(now)
+		return false
+	}
+	return true
+}
+
+// scheduleRotation sets the next rotation to the first interval boundary after now,
+// delayed by a random amount within the configured jitter.
+func (l *RollingFile) scheduleRotation(now time.Time) {
+	if l.rotationInterval <= 0 {
+		return
+	}
+	next := nextBoundary(now, l.rotationInterval)
+	if l.rotationJitter > 0 {
+		next = next.Add(rand.N(l.rotationJitter))
+	}
+	l.nextRotation.Store(next.UnixNano())
+}
+
+// nextBoundary returns the first multiple of d after t, aligned to local time,
+// so that e.g. a 24h interval rotates at local midnight.
+func nextBoundary(t time.Time, d time.Duration) time.Time {
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(d).Add(d).Add(-shift)
+}
+
+// LastRotation returns the time of the last rotation performed by this RollingFile,
+// or the zero time if it has not rotated yet. It is safe to call concurrently with Write.
+func (l *RollingFile) LastRotation() time.Time {
+	return unixNanoTime(l.lastRotation.Load())
+}
+
+// NextRotation returns the time of the next scheduled time-based rotation,
+// or the zero time if no rotation interval is configured. The rotation is performed
+// by the first write at or after that time. It is safe to call concurrently with Write.
+func (l *RollingFile) NextRotation() time.Time {
+	return unixNanoTime(l.nextRotation.Load())
+}
+
+func unixNanoTime(nsec int64) time.Time {
+	if nsec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nsec)
+}
@@ -0,0 +1,71 @@
+package rollingfile
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRotationSchedule verifies that time-based rotation honors and reports its schedule.
+func TestRotationSchedule(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "schedule.log")
+	logger, err := New(logPath, WithRotationInterval(time.Hour))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	assert.True(t, logger.LastRotation().IsZero())
+	next := logger.NextRotation()
+	assert.True(t, next.After(time.Now()))
+	assert.LessOrEqual(t, time.Until(next), time.Hour)
+
+	_, err = logger.Write([]byte("before\n"))
+	assert.NoError(t, err)
+
+	// Pretend the boundary has passed.
+	logger.nextRotation.Store(time.Now().Add(-time.Second).UnixNano())
+	_, err = logger.Write([]byte("after\n"))
+	assert.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now(), logger.LastRotation(), time.Second)
+	assert.True(t, logger.NextRotation().After(time.Now()))
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+// TestRotationScheduleSkipsEmptyFile ensures that an empty file is not rotated when the interval elapses.
+func TestRotationScheduleSkipsEmptyFile(t *testing.T) {
+	logger := &RollingFile{rotationInterval: time.Hour}
+	logger.nextRotation.Store(time.Now().Add(-time.Second).UnixNano())
+
+	assert.False(t, logger.rotationDue(time.Now()))
+	assert.True(t, logger.NextRotation().After(time.Now()))
+}
+
+// TestNextBoundaryIsLocal verifies that boundaries are aligned to local time.
+func TestNextBoundaryIsLocal(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, loc)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, loc), nextBoundary(now, 24*time.Hour).In(loc))
+	assert.Equal(t, time.Date(2024, 3, 10, 16, 0, 0, 0, loc), nextBoundary(now, time.Hour).In(loc))
+}
+
+// TestRotationJitter verifies that scheduled rotations are spread within the jitter window.
+func TestRotationJitter(t *testing.T) {
+	logger := &RollingFile{rotationInterval: time.Hour, rotationJitter: 10 * time.Minute}
+	now := time.Now()
+	boundary := nextBoundary(now, time.Hour)
+
+	seen := map[time.Time]bool{}
+	for i := 0; i < 20; i++ {
+		logger.scheduleRotation(now)
+		next := logger.NextRotation()
+		assert.False(t, next.Before(boundary))
+		assert.Less(t, next.Sub(boundary), 10*time.Minute)
+		seen[next] = true
+	}
+	assert.Greater(t, len(seen), 1)
+}
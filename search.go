@@ -0,0 +1,115 @@
+package rollingfile
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"time"
+)
+
+// ErrStopSearch can be returned by a search callback to end the search early without an error.
+var ErrStopSearch = errors.New("stop search")
+
+// SearchQuery selects the lines returned by Search.
+type SearchQuery struct {
+	// Pattern matches the lines to return. A nil Pattern matches every line.
+	Pattern *regexp.Regexp
+	// Since and Until restrict the search to lines with a time in [Since, Until). Zero values are unbounded.
+	// Lines without a timestamp take the time of the preceding line; lines without any known time are skipped.
+	Since, Until time.Time
+	// Timestamp extracts the time of a line for Since and Until. Defaults to RFC3339Timestamp.
+	Timestamp TimestampFunc
+}
+
+// SearchMatch is a line found by Search.
+type SearchMatch struct {
+	// File is the file the line was read from.
+	File string
+	// Offset is the offset of the line within the file, after decompression.
+	Offset int64
+	// Time is the time of the line, only set if the query has a time range.
+	Time time.Time
+	// Line is the matching line, including its trailing newline. It is only valid during the callback.
+	Line []byte
+}
+
+// Search scans the backups of the log at path, oldest first, followed by the active file for lines
+// matching the query and calls fn for each of them. Gzip backups are decompressed on the fly and
+// backups rotated before the start of the time range are skipped without being read. Backups compressed
+// by an external command (see WithCompressionCommand) cannot be read and make Search fail.
+// Returning ErrStopSearch from fn ends the search early; any other error is returned by Search.
+func Search(path string, q SearchQuery, fn func(SearchMatch) error) error {
+	return (&RollingFile{path: path, fs: osFS{}}).search(q, fn)
+}
+
+// Search scans the retained backups, also in a backup directory (see WithBackupDir), and the active file,
+// see Search.
+func (l *RollingFile) Search(q SearchQuery, fn func(SearchMatch) error) error {
+	return l.search(q, fn)
+}
+
+func (l *RollingFile) search(q SearchQuery, fn func(SearchMatch) error) error {
+	files, err := l.logSetFiles()
+	if err != nil {
+		return err
+	}
+	timestamp := q.Timestamp
+	if timestamp == nil {
+		timestamp = RFC3339Timestamp
+	}
+	ranged := !q.Since.IsZero() || !q.Until.IsZero()
+	if !q.Since.IsZero() {
+		files = skipRotatedBefore(files, q.Since)
+	}
+
+	r := &lineReader{fs: l.fs, files: files}
+	defer r.close()
+	var last time.Time
+	var lastFile string
+	for {
+		file, offset, line, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		match := SearchMatch{File: file, Offset: offset, Line: line}
+		if ranged {
+			if file != lastFile {
+				// Continuation lines at the start of a file do not belong to the previous file.
+				last, lastFile = time.Time{}, file
+			}
+			last = lineTime(timestamp, file, line, last)
+			if last.IsZero() || (!q.Since.IsZero() && last.Before(q.Since)) || (!q.Until.IsZero() && !last.Before(q.Until)) {
+				continue
+			}
+			match.Time = last
+		}
+		if q.Pattern != nil && !q.Pattern.Match(line) {
+			continue
+		}
+		if err := fn(match); err != nil {
+			if errors.Is(err, ErrStopSearch) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// skipRotatedBefore drops the backups whose rotation timestamp shows that all of their
+// lines were written before since. Backup timestamps have a resolution of one second.
+func skipRotatedBefore(files []string, since time.Time) []string {
+	kept := files[:0:0]
+	for _, file := range files {
+		if m := backupTimestampRegexp.FindStringSubmatch(file); len(m) == 2 {
+			ts, err := time.ParseInLocation(backupTimeLayout, m[1], time.Local)
+			if err == nil && ts.Add(time.Second).Before(since) {
+				continue
+			}
+		}
+		kept = append(kept, file)
+	}
+	return kept
+}
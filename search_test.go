@@ -0,0 +1,81 @@
+package rollingfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSearchAttributesMatches verifies that matching lines are found in compressed backups and the
+// active file, with their file and offset.
+func TestSearchAttributesMatches(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "search.log")
+	backup := logPath + "." + time.Now().Format(backupTimeLayout) + ".0.gz"
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("req=1 start\nreq=2 start\nreq=1 done\n"))
+	zw.Close()
+	assert.NoError(t, os.WriteFile(backup, gz.Bytes(), 0644))
+
+	logger, err := New(logPath)
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("req=3 start\nreq=1 retry\n"))
+	assert.NoError(t, err)
+
+	var matches []SearchMatch
+	err = logger.Search(SearchQuery{Pattern: regexp.MustCompile(`req=1\b`)}, func(m SearchMatch) error {
+		m.Line = bytes.Clone(m.Line)
+		matches = append(matches, m)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, []SearchMatch{
+		{File: backup, Offset: 0, Line: []byte("req=1 start\n")},
+		{File: backup, Offset: 24, Line: []byte("req=1 done\n")},
+		{File: logPath, Offset: 12, Line: []byte("req=1 retry\n")},
+	}, matches)
+}
+
+// TestSearchTimeRange verifies that Since and Until filter lines by their timestamp, that continuation
+// lines follow their record and that the search can be stopped early.
+func TestSearchTimeRange(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "range.log")
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local).Format(backupTimeLayout)
+	assert.NoError(t, os.WriteFile(logPath+"."+old+".0", []byte("2019-12-31T23:59:00Z skipped\n"), 0644))
+	assert.NoError(t, os.WriteFile(logPath, []byte("2024-01-01T10:00:00Z a\n"+
+		"2024-01-01T11:00:00Z b\n"+
+		"  trace of b\n"+
+		"2024-01-01T12:00:00Z c\n"+
+		"2024-01-01T13:00:00Z d\n"), 0644))
+
+	var lines []string
+	err := Search(logPath, SearchQuery{
+		Since: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC),
+		Until: time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+	}, func(m SearchMatch) error {
+		lines = append(lines, string(m.Line))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"2024-01-01T11:00:00Z b\n",
+		"  trace of b\n",
+		"2024-01-01T12:00:00Z c\n",
+	}, lines)
+
+	var count int
+	err = Search(logPath, SearchQuery{}, func(SearchMatch) error {
+		count++
+		return ErrStopSearch
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,80 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sequenceWidth is the number of digits sequence numbers are padded to,
+// so backups sort by sequence when ordered by name.
+const sequenceWidth = 12
+
+// sequencePath returns the path of the sidecar file holding the rotation sequence of path.
+// It is hidden and does not share the backup prefix, so cleanup never treats it as a backup.
+func sequencePath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".seq")
+}
+
+// loadSequence reads the last rotation sequence number from the sidecar file.
+// A missing sidecar file starts the sequence at zero.
+func (l *RollingFile) loadSequence() error {
+	data, err := l.readFile(sequencePath(l.path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sequence file %q: %w", sequencePath(l.path), err)
+	}
+	l.sequence = seq
+	return nil
+}
+
+// storeSequence atomically replaces the sidecar file with seq, syncing the directory so that
+// the rename survives a crash and a sequence number is never handed out twice.
+func (l *RollingFile) storeSequence(seq uint64) error {
+	path := sequencePath(l.path)
+	tmpPath := path + tmpSuffix
+	if err := l.createFile(tmpPath, []byte(strconv.FormatUint(seq, 10)+"\n"), l.mode); err != nil {
+		return err
+	}
+	if err := l.fs.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	l.syncDir(filepath.Dir(path))
+	return nil
+}
+
+// sequencedBackupPath reserves the next sequence number and returns the backup path for it, reserved
+// like in uniqueBackupPath. Sequence numbers whose backup already exists are skipped. The new sequence number is
+// persisted before it is used, so numbering stays strictly increasing across restarts.
+func (l *RollingFile) sequencedBackupPath(timestamp string) (string, error) {
+	seq := l.sequence
+	for {
+		seq++
+		backupPath := fmt.Sprintf("%s.%0*d.%s", l.backupPrefix(l.file.Name()), sequenceWidth, seq, timestamp)
+		if l.backupExists(backupPath) {
+			continue
+		}
+		err := l.reserveBackup(backupPath, l.mode)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to reserve backup file name: %w", err)
+		}
+		if err := l.storeSequence(seq); err != nil {
+			l.fs.Remove(backupPath)
+			return "", fmt.Errorf("failed to persist rotation sequence: %w", err)
+		}
+		l.sequence = seq
+		return backupPath, nil
+	}
+}
@@ -0,0 +1,88 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPersistentSequenceAcrossRestarts verifies that backup numbering continues after reopening.
+func TestPersistentSequenceAcrossRestarts(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "seq.log")
+	msg := []byte(strings.Repeat("s", 80) + "\n")
+
+	for run := 0; run < 2; run++ {
+		logger, err := New(logPath,
+			WithMaxBytes(100),
+			WithPersistentSequence(),
+		)
+		assert.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err := logger.Write(msg)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, logger.Close())
+	}
+
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	sort.Strings(files)
+	assert.Len(t, files, 5)
+	for i, f := range files {
+		seq := strings.Split(strings.TrimPrefix(f, logPath+"."), ".")[0]
+		assert.Equal(t, []string{"000000000001", "000000000002", "000000000003", "000000000004", "000000000005"}[i], seq)
+	}
+
+	state, err := os.ReadFile(sequencePath(logPath))
+	assert.NoError(t, err)
+	assert.Equal(t, "5\n", string(state))
+}
+
+// TestPersistentSequenceBackupsExpire ensures that sequenced backup names are still subject to age-based expiry.
+func TestPersistentSequenceBackupsExpire(t *testing.T) {
+	logger := &RollingFile{maxDays: 1}
+	expired, err := logger.isOlderThanFilename("app.log.000000000007.20000101-000000.gz")
+	assert.NoError(t, err)
+	assert.True(t, expired)
+}
+
+// TestPersistentSequenceMixedDirectory ensures that backups from before WithPersistentSequence
+// was enabled are treated as older than sequenced ones, so retention removes them first.
+func TestPersistentSequenceMixedDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "mixed.log")
+	legacy := []string{logPath + ".20990101-000000", logPath + ".20990102-000000"}
+	for _, f := range legacy {
+		assert.NoError(t, os.WriteFile(f, []byte("legacy\n"), 0644))
+	}
+
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMaxBackups(2),
+		WithPersistentSequence(),
+	)
+	assert.NoError(t, err)
+
+	backups, err := logger.listBackups(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, backups)
+
+	msg := []byte(strings.Repeat("s", 80) + "\n")
+	for i := 0; i < 3; i++ {
+		_, err := logger.Write(msg)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	backups, err = logger.listBackups(logPath)
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		assert.Contains(t, backups[0], ".000000000001.")
+		assert.Contains(t, backups[1], ".000000000002.")
+	}
+}
@@ -0,0 +1,40 @@
+package rollingfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseSize verifies parsing of decimal, binary and unit-less sizes.
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"100":     100,
+		"100B":    100,
+		"10KB":    10_000,
+		"10k":     10 << 10,
+		"10 KiB":  10 << 10,
+		"250MB":   250_000_000,
+		"250mib":  250 << 20,
+		"1.5GiB":  3 << 29,
+		"2G":      2 << 30,
+		"1TB":     1_000_000_000_000,
+		" 64 MB ": 64_000_000,
+	}
+	for input, expected := range cases {
+		size, err := ParseSize(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, size, input)
+	}
+
+	for _, input := range []string{"", "MB", "10XB", "-5MB", "1e3", "99999999999TB"} {
+		_, err := ParseSize(input)
+		assert.Error(t, err, input)
+	}
+}
+
+// TestWithMaxSizeInvalid ensures that New reports an invalid size string.
+func TestWithMaxSizeInvalid(t *testing.T) {
+	_, err := New(t.TempDir()+"/size.log", WithMaxSize("ten megabytes"))
+	assert.Error(t, err)
+}
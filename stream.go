@@ -0,0 +1,33 @@
+package rollingfile
+
+import (
+	"errors"
+	"os"
+)
+
+// streamBackup hands the content of backupPath to the stream function. If the function succeeds
+// and discarding is enabled, the local backup is removed. It reports whether the backup was removed.
+func (l *RollingFile) streamBackup(backupPath string) (bool, error) {
+	file, err := l.open(backupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Already removed by cleanup.
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	info, err := file.Stat()
+	if err == nil {
+		err = l.streamFunc(backupPath, file)
+	}
+	file.Close()
+	if err != nil || !l.streamDiscard {
+		return false, err
+	}
+
+	if err := l.removeBackup(backupPath); err != nil {
+		return false, err
+	}
+	l.backupBytes.Add(-info.Size())
+	return true, nil
+}
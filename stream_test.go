@@ -0,0 +1,68 @@
+package rollingfile
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRotationStreamDiscardsBackups verifies that rotated content is streamed and the local copy removed.
+func TestRotationStreamDiscardsBackups(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "stream.log")
+	var mu sync.Mutex
+	var streamed []string
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithRotationStream(func(backupPath string, r io.Reader) error {
+			data, err := io.ReadAll(r)
+			mu.Lock()
+			streamed = append(streamed, string(data))
+			mu.Unlock()
+			return err
+		}, true),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("s", 80) + "\n"
+	for i := 0; i < 3; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	assert.Equal(t, []string{msg, msg}, streamed)
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+// TestRotationStreamFailureKeepsBackup ensures that a failed stream keeps the backup and reports the error.
+func TestRotationStreamFailureKeepsBackup(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "stream.log")
+	errs := make(chan error, 1)
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithRotationStream(func(string, io.Reader) error {
+			return errors.New("upload failed")
+		}, true),
+		WithErrorHandler(func(err error) { errs <- err }),
+	)
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("s", 80) + "\n"
+	for i := 0; i < 2; i++ {
+		_, err := logger.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	assert.ErrorContains(t, <-errs, "upload failed")
+	files, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,65 @@
+//go:build linux || darwin || freebsd
+
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStrictMode verifies that the active file and backups get the exact mode despite a restrictive umask,
+// which only narrows the mode without strict mode.
+func TestStrictMode(t *testing.T) {
+	defer syscall.Umask(syscall.Umask(0077))
+
+	for _, tt := range []struct {
+		name     string
+		options  []Option
+		expected os.FileMode
+	}{
+		{"umask", nil, 0600},
+		{"strict", []Option{WithStrictMode()}, 0644},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			logPath := filepath.Join(tmpDir, "app.log")
+			logger, err := New(logPath, append(tt.options, WithMode(0644), WithMaxBytes(10), WithCompression())...)
+			assert.NoError(t, err)
+			logger.Write([]byte("first\n"))
+			logger.Write([]byte("second\n"))
+			assert.NoError(t, logger.Close())
+
+			files, err := filepath.Glob(logPath + "*")
+			assert.NoError(t, err)
+			assert.Len(t, files, 2)
+			for _, file := range files {
+				info, err := os.Stat(file)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, info.Mode().Perm(), file)
+			}
+		})
+	}
+}
+
+// TestStrictModeKeepsExistingFile verifies that the mode of an existing file is not changed.
+func TestStrictModeKeepsExistingFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(logPath, []byte("existing\n"), 0600))
+	assert.NoError(t, os.Chmod(logPath, 0600))
+	logger, err := New(logPath, WithMode(0644), WithStrictMode())
+	assert.NoError(t, err)
+	_, err = logger.Write([]byte("line\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	info, err := os.Stat(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "existing\nline\n", string(data))
+}
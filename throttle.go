@@ -0,0 +1,32 @@
+package rollingfile
+
+import "time"
+
+// rotationThrottled reports whether the rotation rate cap (see WithRotationRateLimit) postpones a rotation at now,
+// emitting RotationThrottled when it engages.
+func (l *RollingFile) rotationThrottled(now time.Time) bool {
+	if l.rotationLimit == 0 || len(l.recentRotations) < l.rotationLimit {
+		return false
+	}
+	until := l.recentRotations[0].Add(l.rotationWindow)
+	if !now.Before(until) {
+		return false
+	}
+	if !l.throttleNotified {
+		l.throttleNotified = true
+		l.queueEvent(RotationThrottled{Path: l.file.Name(), Until: until})
+	}
+	return true
+}
+
+// recordRotation remembers the time of the last rotations for the rotation rate cap.
+func (l *RollingFile) recordRotation(now time.Time) {
+	if l.rotationLimit == 0 {
+		return
+	}
+	if len(l.recentRotations) == l.rotationLimit {
+		l.recentRotations = l.recentRotations[1:]
+	}
+	l.recentRotations = append(l.recentRotations, now)
+	l.throttleNotified = false
+}
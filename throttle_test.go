@@ -0,0 +1,52 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRotationRateLimit verifies that rotations beyond the cap are postponed until the window has passed,
+// with the active file exceeding the maximum size meanwhile, and that the cap engaging is reported once.
+func TestRotationRateLimit(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	var throttled []RotationThrottled
+	logger, err := New(logPath, WithMaxBytes(10), WithRotationRateLimit(2, time.Minute),
+		WithClock(func() time.Time { return now }),
+		WithEventFunc(func(e Event) {
+			if e, ok := e.(RotationThrottled); ok {
+				throttled = append(throttled, e)
+			}
+		}))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	for i := 0; i < 6; i++ {
+		_, err = logger.Write([]byte("line\n"))
+		assert.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+	assert.EqualValues(t, 2, logger.Stats().Rotations)
+	if assert.Len(t, throttled, 1) {
+		assert.Equal(t, logPath, throttled[0].Path)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 1, 1, 0, time.Local), throttled[0].Until)
+	}
+	info, err := os.Stat(logPath)
+	assert.NoError(t, err)
+	assert.Greater(t, info.Size(), int64(10))
+
+	now = now.Add(time.Minute)
+	_, err = logger.Write([]byte("line\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, logger.Stats().Rotations)
+}
+
+// TestRotationRateLimitInvalid verifies that invalid limits are rejected.
+func TestRotationRateLimitInvalid(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "app.log"), WithRotationRateLimit(0, time.Minute))
+	assert.ErrorContains(t, err, "invalid rotation rate limit")
+}
@@ -0,0 +1,117 @@
+package rollingfile
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// LineEnding selects the line ending that written data is normalized to.
+type LineEnding int
+
+const (
+	// LineEndingLF normalizes CRLF line endings to LF.
+	LineEndingLF LineEnding = iota + 1
+	// LineEndingCRLF normalizes LF line endings to CRLF.
+	LineEndingCRLF
+)
+
+// transform applies the configured per-write transformations to p.
+// It returns p unchanged if no transformation applies.
+func (l *RollingFile) transform(p []byte) []byte {
+	switch l.lineEnding {
+	case LineEndingLF:
+		p = toLF(p)
+	case LineEndingCRLF:
+		p = toCRLF(p, l.lastByte == '\r')
+	}
+	if l.linePrefix != nil {
+		p = prefixLines(p, l.linePrefix, l.lastByte == 0 || l.lastByte == '\n')
+	}
+	return p
+}
+
+// prefixLines inserts the result of prefix at the start of every line in p.
+// atLineStart reports whether p begins a new line. prefix is called at most
+// once per call, so all lines of a single write share the same prefix.
+func prefixLines(p []byte, prefix func() []byte, atLineStart bool) []byte {
+	starts := bytes.Count(p[:len(p)-1], []byte("\n"))
+	if atLineStart {
+		starts++
+	}
+	if starts == 0 {
+		return p
+	}
+	pre := prefix()
+	out := make([]byte, 0, len(p)+starts*len(pre))
+	for len(p) > 0 {
+		if atLineStart {
+			out = append(out, pre...)
+		}
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			out = append(out, p...)
+			break
+		}
+		out = append(out, p[:i+1]...)
+		p = p[i+1:]
+		atLineStart = true
+	}
+	return out
+}
+
+// toLF replaces every CRLF in p with LF.
+// A CRLF split across two writes is not normalized.
+func toLF(p []byte) []byte {
+	if !bytes.Contains(p, []byte("\r\n")) {
+		return p
+	}
+	return bytes.ReplaceAll(p, []byte("\r\n"), []byte("\n"))
+}
+
+// toCRLF replaces every LF in p that is not already preceded by CR with CRLF.
+// afterCR reports whether the previously written byte was a CR.
+func toCRLF(p []byte, afterCR bool) []byte {
+	count := bytes.Count(p, []byte("\n"))
+	if count == 0 {
+		return p
+	}
+	out := make([]byte, 0, len(p)+count)
+	prevCR := afterCR
+	for _, b := range p {
+		if b == '\n' && !prevCR {
+			out = append(out, '\r')
+		}
+		out = append(out, b)
+		prevCR = b == '\r'
+	}
+	return out
+}
+
+// truncateLine shortens p to at most maxSize bytes, replacing the tail with a
+// "...[truncated N bytes]" marker. A trailing newline is preserved and the cut
+// never splits a UTF-8 sequence. If maxSize cannot hold the marker, p is returned unchanged.
+func truncateLine(p []byte, maxSize int64) []byte {
+	var newline []byte
+	if bytes.HasSuffix(p, []byte("\n")) {
+		newline = []byte("\n")
+	}
+	dropped := len(p) - int(maxSize)
+	for {
+		marker := fmt.Sprintf("...[truncated %d bytes]", dropped)
+		keep := int(maxSize) - len(marker) - len(newline)
+		if keep < 0 {
+			return p
+		}
+		for keep > 0 && !utf8.RuneStart(p[keep]) {
+			keep--
+		}
+		if len(p)-keep == dropped {
+			out := make([]byte, 0, int(maxSize))
+			out = append(out, p[:keep]...)
+			out = append(out, marker...)
+			return append(out, newline...)
+		}
+		dropped = len(p) - keep
+	}
+}
@@ -0,0 +1,99 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLineEndingNormalization verifies that line endings are normalized on write
+// while Write still reports the number of bytes consumed from the caller.
+func TestLineEndingNormalization(t *testing.T) {
+	cases := []struct {
+		ending   LineEnding
+		writes   []string
+		expected string
+	}{
+		{LineEndingLF, []string{"a\r\nb\n", "c\r\n"}, "a\nb\nc\n"},
+		{LineEndingCRLF, []string{"a\nb\r\n", "c\n"}, "a\r\nb\r\nc\r\n"},
+		{LineEndingCRLF, []string{"a\r", "\nb\n"}, "a\r\nb\r\n"},
+	}
+	for _, c := range cases {
+		logPath := filepath.Join(t.TempDir(), "newline.log")
+		logger, err := New(logPath, WithLineEnding(c.ending))
+		assert.NoError(t, err)
+
+		for _, w := range c.writes {
+			n, err := logger.Write([]byte(w))
+			assert.NoError(t, err)
+			assert.Equal(t, len(w), n)
+		}
+		err = logger.Close()
+		assert.NoError(t, err)
+
+		contents, err := os.ReadFile(logPath)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, string(contents))
+	}
+}
+
+// TestLinePrefix verifies that the prefix is applied to every line, including lines
+// spanning several writes and writes containing several lines.
+func TestLinePrefix(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "prefix.log")
+	calls := 0
+	logger, err := New(logPath, WithLinePrefix(func() []byte {
+		calls++
+		return []byte("> ")
+	}))
+	assert.NoError(t, err)
+
+	for _, w := range []string{"one\ntwo\n", "thr", "ee\nfour\nfi", "ve\n"} {
+		n, err := logger.Write([]byte(w))
+		assert.NoError(t, err)
+		assert.Equal(t, len(w), n)
+	}
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "> one\n> two\n> three\n> four\n> five\n", string(contents))
+	assert.Equal(t, 3, calls)
+}
+
+// TestTruncateOversized verifies that oversized lines are truncated to the maximum size with a marker.
+func TestTruncateOversized(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "truncate.log")
+	logger, err := New(logPath,
+		WithMaxBytes(50),
+		WithTruncateOversized(),
+	)
+	assert.NoError(t, err)
+
+	line := strings.Repeat("x", 200) + "\n"
+	n, err := logger.Write([]byte(line))
+	assert.NoError(t, err)
+	assert.Equal(t, len(line), n)
+	err = logger.Close()
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", 25)+"...[truncated 176 bytes]\n", string(contents))
+	assert.LessOrEqual(t, len(contents), 50)
+}
+
+// TestTruncateLineKeepsRunes ensures that truncation never splits a multi-byte character.
+func TestTruncateLineKeepsRunes(t *testing.T) {
+	truncated := truncateLine([]byte(strings.Repeat("é", 40)), 30)
+	assert.True(t, utf8.Valid(truncated))
+	assert.LessOrEqual(t, len(truncated), 30)
+
+	// A maximum size too small for the marker leaves the line unchanged.
+	assert.Equal(t, "abcdef", string(truncateLine([]byte("abcdef"), 5)))
+}
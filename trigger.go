@@ -0,0 +1,33 @@
+package rollingfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultTriggerPath returns the default trigger file path for the log file at path.
+// It is hidden and does not share the backup prefix, so cleanup never treats it as a backup.
+func defaultTriggerPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".rotate")
+}
+
+// rotationTriggered reports whether the trigger file (see WithTriggerFile) appeared since the last check,
+// removing it. The trigger file is checked at most once per trigger interval. A trigger for an empty file
+// is consumed without rotating, so no empty backups are created.
+func (l *RollingFile) rotationTriggered(now time.Time) bool {
+	if !l.trigger || now.Sub(l.lastTriggerCheck) < l.triggerInterval {
+		return false
+	}
+	l.lastTriggerCheck = now
+	// Removing the trigger checks for and consumes it in a single call.
+	if err := l.fs.Remove(l.triggerPath); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			l.errorHandler(fmt.Errorf("failed to remove rotation trigger file: %w", err))
+		}
+		return false
+	}
+	return l.size > 0
+}
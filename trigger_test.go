@@ -0,0 +1,82 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTriggerFile verifies that the appearance of the trigger file rotates the file and that the trigger is removed.
+func TestTriggerFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	trigger := filepath.Join(tmpDir, ".app.log.rotate")
+	logger, err := New(logPath, WithTriggerFile("", 0))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	// A trigger for an empty file is consumed without rotating.
+	assert.NoError(t, os.WriteFile(trigger, nil, 0644))
+	logger.Write([]byte("first\n"))
+	assert.NoFileExists(t, trigger)
+	assert.EqualValues(t, 0, logger.Stats().Rotations)
+
+	logger.Write([]byte("second\n"))
+	assert.NoError(t, os.WriteFile(trigger, nil, 0644))
+	logger.Write([]byte("third\n"))
+	assert.NoFileExists(t, trigger)
+	assert.EqualValues(t, 1, logger.Stats().Rotations)
+
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "third\n", string(data))
+}
+
+// TestTriggerFileInterval verifies that the trigger file is only checked once per interval.
+func TestTriggerFileInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	trigger := filepath.Join(tmpDir, "force-rotate")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	logger, err := New(logPath, WithTriggerFile(trigger, time.Minute), WithClock(func() time.Time { return now }))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	logger.Write([]byte("first\n"))
+	assert.NoError(t, os.WriteFile(trigger, nil, 0644))
+	logger.Write([]byte("second\n"))
+	assert.FileExists(t, trigger)
+
+	now = now.Add(time.Minute)
+	logger.Write([]byte("third\n"))
+	assert.NoFileExists(t, trigger)
+	assert.EqualValues(t, 1, logger.Stats().Rotations)
+}
+
+// TestTriggerFileNotABackup ensures that a pending trigger file named like a backup of the log file
+// is neither listed nor removed by cleanup.
+func TestTriggerFileNotABackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	trigger := logPath + ".rotate"
+	logger, err := New(logPath, WithTriggerFile(trigger, time.Hour), WithMaxBytes(10), WithMaxBackups(1))
+	assert.NoError(t, err)
+
+	// The first write checks for the trigger, which is then not checked again within the interval.
+	for i := 0; i < 3; i++ {
+		_, err := logger.Write([]byte("0123456\n"))
+		assert.NoError(t, err)
+		if i == 0 {
+			assert.NoError(t, os.WriteFile(trigger, nil, 0644))
+		}
+	}
+	assert.NoError(t, logger.Close())
+
+	assert.FileExists(t, trigger)
+	backups, err := logger.listBackups(logPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, backups, trigger)
+}
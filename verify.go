@@ -0,0 +1,153 @@
+package rollingfile
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VerifyReport is the result of verifying the backups of a log file.
+type VerifyReport struct {
+	// Verified lists the backups whose integrity was confirmed.
+	Verified []string
+	// Unverified lists the backups that have no metadata sidecar and could not be fully checked.
+	// Gzip backups without a sidecar are still checked for gzip integrity.
+	Unverified []string
+	// Problems lists missing and corrupted backups.
+	Problems []VerifyProblem
+}
+
+// VerifyProblem describes a missing or corrupted backup.
+type VerifyProblem struct {
+	Path string
+	Err  error
+}
+
+// OK reports whether no problems were found.
+func (r VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify checks the integrity of the retained backups of the log file at path. Backups with a metadata
+// sidecar (see WithMetadata) are checked against the recorded sizes and checksums, gzip backups are
+// fully decompressed to validate them, and sidecars whose backup no longer exists are reported as missing.
+// The returned error is only non-nil if the backups could not be listed.
+func Verify(path string) (VerifyReport, error) {
+	return (&RollingFile{path: path, fs: osFS{}}).verify()
+}
+
+func (l *RollingFile) verify() (VerifyReport, error) {
+	var report VerifyReport
+	backups, err := l.listBackups(l.path)
+	if err != nil {
+		return report, err
+	}
+	prefix := l.backupPrefix(l.path)
+	sidecars, err := l.fs.Glob(prefix + ".*" + metadataSuffix)
+	if err != nil {
+		return report, err
+	}
+
+	described := map[string]bool{}
+	for _, sidecar := range sidecars {
+		data, err := l.readFile(sidecar)
+		var meta BackupMetadata
+		if err == nil {
+			err = json.Unmarshal(data, &meta)
+		}
+		if err != nil {
+			report.Problems = append(report.Problems, VerifyProblem{Path: sidecar, Err: fmt.Errorf("unreadable metadata: %w", err)})
+			continue
+		}
+		backup := filepath.Join(filepath.Dir(prefix), meta.File)
+		described[backup] = true
+		if err = l.verifyBackup(backup, meta); err != nil {
+			report.Problems = append(report.Problems, VerifyProblem{Path: backup, Err: err})
+			continue
+		}
+		report.Verified = append(report.Verified, backup)
+	}
+
+	for _, backup := range backups {
+		if described[backup] {
+			continue
+		}
+		if strings.HasSuffix(backup, compressedSuffix) {
+			if _, _, _, err := l.digestGzip(backup); err != nil {
+				report.Problems = append(report.Problems, VerifyProblem{Path: backup, Err: err})
+				continue
+			}
+		}
+		report.Unverified = append(report.Unverified, backup)
+	}
+	return report, nil
+}
+
+// Verify checks the integrity of the retained backups of the RollingFile (see the Verify function),
+// also in a backup directory (see WithBackupDir). It does not run concurrently with backup cleanup.
+func (l *RollingFile) Verify() (VerifyReport, error) {
+	l.cleanupMutex.Lock()
+	defer l.cleanupMutex.Unlock()
+	return l.verify()
+}
+
+// verifyBackup checks a backup against its metadata.
+func (l *RollingFile) verifyBackup(backup string, meta BackupMetadata) error {
+	if _, err := l.fs.Stat(backup); errors.Is(err, os.ErrNotExist) {
+		return errors.New("backup file is missing")
+	}
+
+	if meta.CompressedSHA256 != "" {
+		size, sum, _, err := l.digestFile(backup)
+		if err != nil {
+			return err
+		}
+		if size != meta.CompressedBytes || sum != meta.CompressedSHA256 {
+			return errors.New("compressed file does not match its checksum")
+		}
+	}
+
+	var size int64
+	var sum string
+	var err error
+	switch meta.Codec {
+	case "none":
+		size, sum, _, err = l.digestFile(backup)
+	case "gzip":
+		size, sum, _, err = l.digestGzip(backup)
+	default:
+		// Content compressed by an external command can only be checked by its compressed checksum.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if size != meta.Bytes || sum != meta.SHA256 {
+		return errors.New("content does not match its checksum")
+	}
+	return nil
+}
+
+// digestGzip decompresses a gzip file completely, which validates its checksums,
+// and returns the size, SHA-256 checksum and line count of the uncompressed content.
+func (l *RollingFile) digestGzip(path string) (size int64, sum string, lines int64, err error) {
+	file, err := l.open(path)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("invalid gzip file: %w", err)
+	}
+	defer gz.Close()
+	size, sum, lines, err = digest(gz)
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("invalid gzip file: %w", err)
+	}
+	return size, sum, lines, nil
+}
@@ -0,0 +1,70 @@
+package rollingfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVerifyDetectsMissingAndCorruptedBackups verifies that intact backups pass and that
+// missing and modified backups are reported.
+func TestVerifyDetectsMissingAndCorruptedBackups(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "verify.log")
+	logger, err := New(logPath,
+		WithMaxBytes(100),
+		WithMetadata(),
+		WithMaxUncompressedBackups(1),
+		WithCompression(),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err := logger.Write([]byte(strings.Repeat(string(rune('a'+i)), 80) + "\n"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, logger.Close())
+
+	report, err := Verify(logPath)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Len(t, report.Verified, 3)
+
+	backups, err := logger.listBackups(logPath)
+	assert.NoError(t, err)
+	var plain, compressed []string
+	for _, b := range backups {
+		if strings.HasSuffix(b, compressedSuffix) {
+			compressed = append(compressed, b)
+		} else {
+			plain = append(plain, b)
+		}
+	}
+	assert.Len(t, plain, 1)
+	assert.Len(t, compressed, 2)
+
+	// Corrupt the uncompressed backup, truncate a compressed one and remove another.
+	assert.NoError(t, os.WriteFile(plain[0], []byte("tampered\n"), 0644))
+	assert.NoError(t, os.Truncate(compressed[0], 10))
+	assert.NoError(t, os.Remove(compressed[1]))
+
+	report, err = Verify(logPath)
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Len(t, report.Problems, 3)
+	assert.Empty(t, report.Verified)
+}
+
+// TestVerifyChecksGzipWithoutMetadata ensures that gzip backups without sidecars are still validated.
+func TestVerifyChecksGzipWithoutMetadata(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "verify.log")
+	assert.NoError(t, os.WriteFile(logPath+".20240101-000000.0.gz", []byte("not gzip"), 0644))
+	assert.NoError(t, os.WriteFile(logPath+".20240101-000001.0", []byte("plain\n"), 0644))
+
+	report, err := Verify(logPath)
+	assert.NoError(t, err)
+	assert.Len(t, report.Problems, 1)
+	assert.Equal(t, []string{logPath + ".20240101-000001.0"}, report.Unverified)
+}